@@ -0,0 +1,166 @@
+package diameter
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// RxQueueLimit is max length of Rx queue.
+	// Request is answered with DiameterTooBusy when the queue is full.
+	// Limit is disabled when value is 0.
+	RxQueueLimit = 0
+	// RxRateLimit is max number of recieved request per second.
+	// Limit is disabled when value is 0.
+	RxRateLimit = 0
+
+	// BreakerThreshold is number of continuous failure
+	// (timeout or DiameterTooBusy answer) that opens circuit breaker.
+	// Circuit breaker is disabled when value is 0.
+	BreakerThreshold = 0
+	// BreakerCooldown is time to half-open circuit breaker
+	BreakerCooldown = time.Second * 30
+)
+
+// admit check inbound request is acceptable or not.
+// Request that has higher priority than ShedPriority is admitted
+// while the Rx queue has space.
+// It must be called in event handler.
+func (c *Conn) admit(m RawMsg) bool {
+	full := len(c.rcvstack) >= cap(c.rcvstack)-1
+	ok := true
+	if full {
+		// keep one space for disconnect notification
+		ok = false
+	} else if RxQueueLimit != 0 && len(c.rcvstack) >= RxQueueLimit {
+		ok = false
+	} else if RxRateLimit != 0 {
+		now := time.Now()
+		if now.Sub(c.rxWindow) >= time.Second {
+			c.rxWindow = now
+			c.rxCount = 0
+		}
+		if c.rxCount >= RxRateLimit {
+			ok = false
+		} else {
+			c.rxCount++
+		}
+	}
+
+	if shed := atomic.LoadInt32(&c.shedding) != 0; ok == shed {
+		if shed {
+			atomic.StoreInt32(&c.shedding, 0)
+		} else {
+			atomic.StoreInt32(&c.shedding, 1)
+			atomic.AddUint64(&ocSeq, 1)
+		}
+		publish(AdmissionEvent{Shed: !shed, Conn: c})
+	}
+	if !full && getPriority(m) <= ShedPriority {
+		return true
+	}
+	return ok
+}
+
+// BreakerState is state of outbound circuit breaker
+type BreakerState int
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "<nil>"
+}
+
+// State of outbound circuit breaker
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+type breaker struct {
+	sync.Mutex
+	state BreakerState
+	fails int
+	since time.Time
+	trial bool
+}
+
+// allow check outbound request can be sent or not
+func (c *Conn) allow() bool {
+	if BreakerThreshold == 0 {
+		return true
+	}
+	c.brk.Lock()
+	old := c.brk.state
+	ok := false
+	switch c.brk.state {
+	case BreakerClosed:
+		ok = true
+	case BreakerOpen:
+		if time.Since(c.brk.since) >= BreakerCooldown {
+			c.brk.state = BreakerHalfOpen
+			c.brk.trial = true
+			ok = true
+		}
+	case BreakerHalfOpen:
+		if !c.brk.trial {
+			c.brk.trial = true
+			ok = true
+		}
+	}
+	now := c.brk.state
+	c.brk.Unlock()
+
+	if old != now {
+		publish(BreakerEvent{OldState: old, NewState: now, Conn: c})
+	}
+	return ok
+}
+
+// report result of outbound request to circuit breaker
+func (c *Conn) report(fail bool) {
+	if BreakerThreshold == 0 {
+		return
+	}
+	c.brk.Lock()
+	old := c.brk.state
+	switch c.brk.state {
+	case BreakerClosed:
+		if !fail {
+			c.brk.fails = 0
+		} else if c.brk.fails++; c.brk.fails >= BreakerThreshold {
+			c.brk.state = BreakerOpen
+			c.brk.since = time.Now()
+		}
+	case BreakerHalfOpen:
+		c.brk.trial = false
+		if fail {
+			c.brk.state = BreakerOpen
+			c.brk.since = time.Now()
+		} else {
+			c.brk.state = BreakerClosed
+			c.brk.fails = 0
+		}
+	}
+	now := c.brk.state
+	c.brk.Unlock()
+
+	if old != now {
+		publish(BreakerEvent{OldState: old, NewState: now, Conn: c})
+	}
+}
+
+// Breaker returns state of circuit breaker
+func (c *Conn) Breaker() BreakerState {
+	c.brk.Lock()
+	defer c.brk.Unlock()
+	return c.brk.state
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"time"
+	"unicode/utf8"
 )
 
 // Enumerated is Enumerated format AVP value
@@ -135,22 +136,38 @@ func (a *RawAVP) Encode(d interface{}) (e error) {
 		} else {
 			e = fmt.Errorf("invalid net.IP struct")
 		}
+	case Address:
+		var b []byte
+		if b, e = d.bytes(); e == nil {
+			_, e = buf.Write(b)
+		}
 	case time.Time:
-		e = binary.Write(buf, binary.BigEndian, int64(d.Unix()+2208988800))
+		var t uint32
+		if t, e = timeToNTP(d); e == nil {
+			e = binary.Write(buf, binary.BigEndian, t)
+		}
 	case Identity:
 		_, e = buf.Write([]byte(d))
 	case URI:
 		_, e = buf.Write([]byte(d.String()))
 	case Enumerated:
 		e = binary.Write(buf, binary.BigEndian, int32(d))
-		//	case IPFilterRule:
-		//		e = a.setIPFilterRuleData(d)
+	case IPFilterRule:
+		_, e = buf.Write([]byte(d.String()))
 	case string:
-		_, e = buf.Write([]byte(d))
+		if !utf8.ValidString(d) {
+			e = fmt.Errorf("invalid UTF-8 string")
+		} else {
+			_, e = buf.Write([]byte(d))
+		}
 	case []RawAVP:
 		for _, avp := range d {
 			_, e = avp.WriteTo(buf)
 		}
+	case GroupedAVP:
+		for _, avp := range d {
+			_, e = avp.WriteTo(buf)
+		}
 	case []byte:
 		_, e = buf.Write(d)
 	case int32, int64, uint32, uint64, float32, float64:
@@ -173,22 +190,25 @@ func (a RawAVP) Decode(d interface{}) (e error) {
 	}
 	switch d := d.(type) {
 	case *net.IP:
-		if len(a.data) == 6 && a.data[0] == 0x00 && a.data[1] == 0x01 {
-			*d = net.IP(a.data[2:6])
-		} else if len(a.data) == 18 && a.data[0] == 0x00 && a.data[1] == 0x02 {
-			*d = net.IP(a.data[2:18])
+		var ad Address
+		if ad, e = parseAddress(a.data); e != nil {
+		} else if ip := ad.IP(); ip != nil {
+			*d = ip
 		} else {
-			e = fmt.Errorf("invalid address family")
+			e = fmt.Errorf("invalid address family %d for IP", ad.Family)
 		}
+	case *Address:
+		*d, e = parseAddress(a.data)
 	case *time.Time:
-		if len(a.data) != 8 {
+		// 8 octets is accepted for the value of old implementation
+		switch len(a.data) {
+		case 4:
+			*d = ntpToTime(binary.BigEndian.Uint32(a.data))
+		case 8:
+			t := int64(binary.BigEndian.Uint64(a.data))
+			*d = time.Unix(t-ntpOffset, 0)
+		default:
 			e = io.EOF
-		} else {
-			buf := bytes.NewReader(a.data)
-			var t uint64
-			if e = binary.Read(buf, binary.BigEndian, &t); e != nil {
-				*d = time.Unix(int64(t-2208988800), int64(0))
-			}
 		}
 	case *Identity:
 		*d, e = ParseIdentity(string(a.data))
@@ -200,14 +220,18 @@ func (a RawAVP) Decode(d interface{}) (e error) {
 		} else {
 			buf := bytes.NewReader(a.data)
 			var t int32
-			if e = binary.Read(buf, binary.BigEndian, &t); e != nil {
+			if e = binary.Read(buf, binary.BigEndian, &t); e == nil {
 				*d = Enumerated(t)
 			}
 		}
-		//	case *IPFilterRule:
-		//		e = a.getIPFilterRuleData(d)
+	case *IPFilterRule:
+		*d, e = ParseIPFilterRule(string(a.data))
 	case *string:
-		*d = string(a.data)
+		if !utf8.Valid(a.data) {
+			e = fmt.Errorf("invalid UTF-8 string")
+		} else {
+			*d = string(a.data)
+		}
 	case *[]RawAVP:
 		*d = make([]RawAVP, 0)
 		for buf := bytes.NewReader(a.data); buf.Len() != 0; {
@@ -217,6 +241,10 @@ func (a RawAVP) Decode(d interface{}) (e error) {
 			}
 			*d = append(*d, avp)
 		}
+	case *GroupedAVP:
+		var g []RawAVP
+		e = a.Decode(&g)
+		*d = g
 	case *[]byte:
 		b := make([]byte, len(a.data))
 		copy(b, a.data)
@@ -240,19 +268,3 @@ func (a RawAVP) Decode(d interface{}) (e error) {
 	}
 	return
 }
-
-/*
-// IPFilterRule is IP Filter Rule format AVP value
-type IPFilterRule string
-
-// IPFilterRule
-func (a *RawAVP) setIPFilterRuleData(s IPFilterRule) (e error) {
-	a.data = []byte(s)
-	return
-}
-
-func (a RawAVP) getIPFilterRuleData(s *IPFilterRule) (e error) {
-	*s = IPFilterRule(a.data)
-	return
-}
-*/
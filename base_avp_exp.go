@@ -1,5 +1,7 @@
 package diameter
 
+import "fmt"
+
 // SetVendorSpecAppID make Vendor-Specific-Application-Id AVP
 func SetVendorSpecAppID(vi, ai uint32) (a RawAVP) {
 	a = RawAVP{Code: 260, VenID: 0, FlgV: false, FlgM: true, FlgP: false}
@@ -32,6 +34,77 @@ func GetVendorSpecAppID(a RawAVP) (vi, ai uint32, e error) {
 	return
 }
 
+// DRMP indicate Diameter Routing Message Priority.
+// Lower value is higher priority.
+type DRMP int
+
+const (
+	// UnknownPriority is no DRMP
+	UnknownPriority DRMP = iota
+	// Priority0 is PRIORITY_0 (highest priority)
+	Priority0
+	// Priority1 is PRIORITY_1
+	Priority1
+	// Priority2 is PRIORITY_2
+	Priority2
+	// Priority3 is PRIORITY_3
+	Priority3
+	// Priority4 is PRIORITY_4
+	Priority4
+	// Priority5 is PRIORITY_5
+	Priority5
+	// Priority6 is PRIORITY_6
+	Priority6
+	// Priority7 is PRIORITY_7
+	Priority7
+	// Priority8 is PRIORITY_8
+	Priority8
+	// Priority9 is PRIORITY_9
+	Priority9
+	// Priority10 is PRIORITY_10 (default priority)
+	Priority10
+	// Priority11 is PRIORITY_11
+	Priority11
+	// Priority12 is PRIORITY_12
+	Priority12
+	// Priority13 is PRIORITY_13
+	Priority13
+	// Priority14 is PRIORITY_14
+	Priority14
+	// Priority15 is PRIORITY_15 (lowest priority)
+	Priority15
+)
+
+func (v DRMP) String() string {
+	if v < Priority0 || v > Priority15 {
+		return "<nil>"
+	}
+	return fmt.Sprintf("PRIORITY_%d", v-Priority0)
+}
+
+// SetDRMP make DRMP AVP
+func SetDRMP(v DRMP) (a RawAVP) {
+	a = RawAVP{Code: 301, VenID: 0, FlgV: false, FlgM: false, FlgP: false}
+	if v >= Priority0 && v <= Priority15 {
+		a.Encode(Enumerated(v - Priority0))
+	}
+	return
+}
+
+// GetDRMP read DRMP AVP
+func GetDRMP(a RawAVP) (v DRMP, e error) {
+	var s int32
+	if a.FlgV || a.FlgP {
+		e = InvalidAVP(DiameterInvalidAvpBits)
+	} else if e = a.Decode(&s); e != nil {
+	} else if s < 0 || s > 15 {
+		e = InvalidAVP(DiameterInvalidAvpValue)
+	} else {
+		v = DRMP(s) + Priority0
+	}
+	return
+}
+
 // SetSessionID make Session-ID AVP
 func SetSessionID(v string) (a RawAVP) {
 	a = RawAVP{Code: 263, VenID: 0, FlgV: false, FlgM: true, FlgP: false}
@@ -138,7 +211,7 @@ func GetAuthSessionState(a RawAVP) (v bool, e error) {
 	s := new(Enumerated)
 	if a.FlgV || !a.FlgM || a.FlgP {
 		e = InvalidAVP(DiameterInvalidAvpBits)
-	} else if e = a.Decode(s); e != nil {
+	} else if e = a.Decode(s); e == nil {
 		switch *s {
 		case 0:
 			v = true
@@ -9,6 +9,7 @@ import (
 /*
 CER is Capabilities-Exchange-Request message
  <CER> ::= < Diameter Header: 257, REQ >
+		   [ DRMP ]
 		   { Origin-Host }
 		   { Origin-Realm }
 		1* { Host-IP-Address }
@@ -24,6 +25,7 @@ CER is Capabilities-Exchange-Request message
 		 * [ AVP ]
 */
 type CER struct {
+	DRMP
 	OriginHost    Identity
 	OriginRealm   Identity
 	HostIPAddress []net.IP
@@ -38,6 +40,9 @@ type CER struct {
 func (v CER) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP            =%s\n", Indent, v.DRMP)
+	}
 	fmt.Fprintf(w, "%sOrigin-Host     =%s\n", Indent, v.OriginHost)
 	fmt.Fprintf(w, "%sOrigin-Realm    =%s\n", Indent, v.OriginRealm)
 	fmt.Fprintf(w, "%sHost-IP-Address =", Indent)
@@ -68,6 +73,9 @@ func (v CER) ToRaw(s string) RawMsg {
 		Code: 257, AppID: 0,
 		AVP: make([]RawAVP, 0, 20)}
 
+	if v.DRMP != UnknownPriority {
+		m.AVP = append(m.AVP, SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, SetOriginHost(v.OriginHost))
 	m.AVP = append(m.AVP, SetOriginRealm(v.OriginRealm))
 	for _, ip := range v.HostIPAddress {
@@ -109,6 +117,8 @@ func (CER) FromRaw(m RawMsg) (Request, string, error) {
 
 	for _, a := range m.AVP {
 		switch a.Code {
+		case 301:
+			v.DRMP, e = GetDRMP(a)
 		case 264:
 			v.OriginHost, e = GetOriginHost(a)
 		case 296:
@@ -169,6 +179,7 @@ func (CER) FromRaw(m RawMsg) (Request, string, error) {
 // Failed make error message for timeout
 func (v CER) Failed(c uint32) Answer {
 	return CEA{
+		DRMP:          v.DRMP,
 		ResultCode:    c,
 		OriginHost:    Host,
 		OriginRealm:   Realm,
@@ -180,6 +191,7 @@ func (v CER) Failed(c uint32) Answer {
 /*
 CEA is Capabilities-Exchange-Answer message
  <CEA> ::= < Diameter Header: 257 >
+		   [ DRMP ]
 		   { Result-Code }
 		   { Origin-Host }
 		   { Origin-Realm }
@@ -198,6 +210,7 @@ CEA is Capabilities-Exchange-Answer message
 		 * [ AVP ]
 */
 type CEA struct {
+	DRMP
 	ResultCode    uint32
 	OriginHost    Identity
 	OriginRealm   Identity
@@ -215,6 +228,9 @@ type CEA struct {
 func (v CEA) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP            =%s\n", Indent, v.DRMP)
+	}
 	fmt.Fprintf(w, "%sResult-Code     =%d\n", Indent, v.ResultCode)
 	fmt.Fprintf(w, "%sOrigin-Host     =%s\n", Indent, v.OriginHost)
 	fmt.Fprintf(w, "%sOrigin-Realm    =%s\n", Indent, v.OriginRealm)
@@ -250,6 +266,9 @@ func (v CEA) ToRaw(s string) RawMsg {
 		AVP: make([]RawAVP, 0, 20)}
 	m.FlgE = v.ResultCode != DiameterSuccess
 
+	if v.DRMP != UnknownPriority {
+		m.AVP = append(m.AVP, SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, SetResultCode(v.ResultCode))
 	m.AVP = append(m.AVP, SetOriginHost(v.OriginHost))
 	m.AVP = append(m.AVP, SetOriginRealm(v.OriginRealm))
@@ -300,6 +319,8 @@ func (CEA) FromRaw(m RawMsg) (Answer, string, error) {
 		switch a.Code {
 		case 268:
 			v.ResultCode, e = GetResultCode(a)
+		case 301:
+			v.DRMP, e = GetDRMP(a)
 		case 264:
 			v.OriginHost, e = GetOriginHost(a)
 		case 296:
@@ -370,12 +391,14 @@ func (v CEA) Result() uint32 {
 /*
 DPR is Disconnect-Peer-Request message
  <DPR>  ::= < Diameter Header: 282, REQ >
+			[ DRMP ]
 			{ Origin-Host }
 			{ Origin-Realm }
 			{ Disconnect-Cause }
 		  * [ AVP ]
 */
 type DPR struct {
+	DRMP
 	OriginHost      Identity
 	OriginRealm     Identity
 	DisconnectCause Enumerated
@@ -384,6 +407,9 @@ type DPR struct {
 func (v DPR) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP            =%s\n", Indent, v.DRMP)
+	}
 	fmt.Fprintf(w, "%sOrigin-Host     =%s\n", Indent, v.OriginHost)
 	fmt.Fprintf(w, "%sOrigin-Realm    =%s\n", Indent, v.OriginRealm)
 	fmt.Fprintf(w, "%sDisconnect-Cause=%d\n", Indent, v.DisconnectCause)
@@ -399,6 +425,9 @@ func (v DPR) ToRaw(s string) RawMsg {
 		Code: 282, AppID: 0,
 		AVP: make([]RawAVP, 0, 3)}
 
+	if v.DRMP != UnknownPriority {
+		m.AVP = append(m.AVP, SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, SetOriginHost(v.OriginHost))
 	m.AVP = append(m.AVP, SetOriginRealm(v.OriginRealm))
 	m.AVP = append(m.AVP, setDisconnectCause(v.DisconnectCause))
@@ -416,6 +445,8 @@ func (DPR) FromRaw(m RawMsg) (Request, string, error) {
 		DisconnectCause: -1}
 	for _, a := range m.AVP {
 		switch a.Code {
+		case 301:
+			v.DRMP, e = GetDRMP(a)
 		case 264:
 			v.OriginHost, e = GetOriginHost(a)
 		case 296:
@@ -440,6 +471,7 @@ func (DPR) FromRaw(m RawMsg) (Request, string, error) {
 // Failed make error message for timeout
 func (v DPR) Failed(c uint32) Answer {
 	return DPA{
+		DRMP:        v.DRMP,
 		ResultCode:  c,
 		OriginHost:  Host,
 		OriginRealm: Realm}
@@ -448,6 +480,7 @@ func (v DPR) Failed(c uint32) Answer {
 /*
 DPA is Disconnect-Peer-Answer message
  <DPA>  ::= < Diameter Header: 282 >
+			[ DRMP ]
 			{ Result-Code }
 			{ Origin-Host }
 			{ Origin-Realm }
@@ -456,6 +489,7 @@ DPA is Disconnect-Peer-Answer message
 		  * [ AVP ]
 */
 type DPA struct {
+	DRMP
 	ResultCode   uint32
 	OriginHost   Identity
 	OriginRealm  Identity
@@ -466,6 +500,9 @@ type DPA struct {
 func (v DPA) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP            =%s\n", Indent, v.DRMP)
+	}
 	fmt.Fprintf(w, "%sResult-Code     =%d\n", Indent, v.ResultCode)
 	fmt.Fprintf(w, "%sOrigin-Host     =%s\n", Indent, v.OriginHost)
 	fmt.Fprintf(w, "%sOrigin-Realm    =%s\n", Indent, v.OriginRealm)
@@ -486,6 +523,9 @@ func (v DPA) ToRaw(s string) RawMsg {
 		AVP: make([]RawAVP, 0, 5)}
 	m.FlgE = v.ResultCode != DiameterSuccess
 
+	if v.DRMP != UnknownPriority {
+		m.AVP = append(m.AVP, SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, SetResultCode(v.ResultCode))
 	m.AVP = append(m.AVP, SetOriginHost(v.OriginHost))
 	m.AVP = append(m.AVP, SetOriginRealm(v.OriginRealm))
@@ -510,6 +550,8 @@ func (DPA) FromRaw(m RawMsg) (Answer, string, error) {
 		switch a.Code {
 		case 268:
 			v.ResultCode, e = GetResultCode(a)
+		case 301:
+			v.DRMP, e = GetDRMP(a)
 		case 264:
 			v.OriginHost, e = GetOriginHost(a)
 		case 296:
@@ -540,12 +582,14 @@ func (v DPA) Result() uint32 {
 /*
 DWR is DeviceWatchdogRequest message
  <DWR>  ::= < Diameter Header: 280, REQ >
+			[ DRMP ]
 			{ Origin-Host }
 			{ Origin-Realm }
 			[ Origin-State-Id ]
 		  * [ AVP ]
 */
 type DWR struct {
+	DRMP
 	OriginHost    Identity
 	OriginRealm   Identity
 	OriginStateID uint32
@@ -554,6 +598,9 @@ type DWR struct {
 func (v DWR) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP            =%s\n", Indent, v.DRMP)
+	}
 	fmt.Fprintf(w, "%sOrigin-Host     =%s\n", Indent, v.OriginHost)
 	fmt.Fprintf(w, "%sOrigin-Realm    =%s\n", Indent, v.OriginRealm)
 	fmt.Fprintf(w, "%sOrigin-State-ID =%d\n", Indent, v.OriginStateID)
@@ -569,6 +616,9 @@ func (v DWR) ToRaw(s string) RawMsg {
 		Code: 280, AppID: 0,
 		AVP: make([]RawAVP, 0, 3)}
 
+	if v.DRMP != UnknownPriority {
+		m.AVP = append(m.AVP, SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, SetOriginHost(v.OriginHost))
 	m.AVP = append(m.AVP, SetOriginRealm(v.OriginRealm))
 	if v.OriginStateID != 0 {
@@ -587,6 +637,8 @@ func (DWR) FromRaw(m RawMsg) (Request, string, error) {
 	v := DWR{}
 	for _, a := range m.AVP {
 		switch a.Code {
+		case 301:
+			v.DRMP, e = GetDRMP(a)
 		case 264:
 			v.OriginHost, e = GetOriginHost(a)
 		case 296:
@@ -609,6 +661,7 @@ func (DWR) FromRaw(m RawMsg) (Request, string, error) {
 // Failed make error message for timeout
 func (v DWR) Failed(c uint32) Answer {
 	return DWA{
+		DRMP:        v.DRMP,
 		ResultCode:  c,
 		OriginHost:  Host,
 		OriginRealm: Realm}
@@ -617,26 +670,33 @@ func (v DWR) Failed(c uint32) Answer {
 /*
 DWA Device-Watchdo-gAnswer message
  <DWA>  ::= < Diameter Header: 280 >
+			[ DRMP ]
 			{ Result-Code }
 			{ Origin-Host }
 			{ Origin-Realm }
 			[ Error-Message ]
 			[ Failed-AVP ]
 			[ Origin-State-Id ]
+		  * [ Load ]
 		  * [ AVP ]
 */
 type DWA struct {
+	DRMP
 	ResultCode    uint32
 	OriginHost    Identity
 	OriginRealm   Identity
 	ErrorMessage  string
 	FailedAVP     []RawAVP
 	OriginStateID uint32
+	Load          []Load
 }
 
 func (v DWA) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP            =%s\n", Indent, v.DRMP)
+	}
 	fmt.Fprintf(w, "%sResult-Code     =%d\n", Indent, v.ResultCode)
 	fmt.Fprintf(w, "%sOrigin-Host     =%s\n", Indent, v.OriginHost)
 	fmt.Fprintf(w, "%sOrigin-Realm    =%s\n", Indent, v.OriginRealm)
@@ -645,6 +705,9 @@ func (v DWA) String() string {
 		fmt.Fprintf(w, "%sFailed-AVP      =\n%s", Indent, avp)
 	}
 	fmt.Fprintf(w, "%sOrigin-State-ID =%d\n", Indent, v.OriginStateID)
+	for _, l := range v.Load {
+		fmt.Fprintf(w, "%sLoad            =%s:%d(%s)\n", Indent, l.Type, l.Value, l.SourceID)
+	}
 
 	return w.String()
 }
@@ -658,6 +721,9 @@ func (v DWA) ToRaw(s string) RawMsg {
 		AVP: make([]RawAVP, 0, 6)}
 	m.FlgE = v.ResultCode != DiameterSuccess
 
+	if v.DRMP != UnknownPriority {
+		m.AVP = append(m.AVP, SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, SetResultCode(v.ResultCode))
 	m.AVP = append(m.AVP, SetOriginHost(v.OriginHost))
 	m.AVP = append(m.AVP, SetOriginRealm(v.OriginRealm))
@@ -670,6 +736,9 @@ func (v DWA) ToRaw(s string) RawMsg {
 	if v.OriginStateID != 0 {
 		m.AVP = append(m.AVP, setOriginStateID(v.OriginStateID))
 	}
+	for _, l := range v.Load {
+		m.AVP = append(m.AVP, SetLoad(l))
+	}
 	return m
 }
 
@@ -685,6 +754,8 @@ func (DWA) FromRaw(m RawMsg) (Answer, string, error) {
 		switch a.Code {
 		case 268:
 			v.ResultCode, e = GetResultCode(a)
+		case 301:
+			v.DRMP, e = GetDRMP(a)
 		case 264:
 			v.OriginHost, e = GetOriginHost(a)
 		case 296:
@@ -695,6 +766,11 @@ func (DWA) FromRaw(m RawMsg) (Answer, string, error) {
 			v.FailedAVP, e = getFailedAVP(a)
 		case 278:
 			v.OriginStateID, e = getOriginStateID(a)
+		case 650:
+			// invalid Load AVP is ignored
+			if l, e := GetLoad(a); e == nil {
+				v.Load = append(v.Load, l)
+			}
 		}
 
 		if e != nil {
@@ -0,0 +1,678 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	dia "github.com/fkgi/diameter"
+)
+
+// field is member of message or Grouped AVP struct
+type field struct {
+	name string
+	avp  dia.DictAVP
+	rule dia.DictRule
+	typ  string
+	// base is true when set/get functions of diameter package are used
+	base bool
+}
+
+func (f field) multi() bool {
+	return f.rule.Max != 1
+}
+
+func (f field) ptr() bool {
+	return !f.multi() && f.rule.Min == 0 && len(emptyCond(f.typ, "")) == 0
+}
+
+func (f field) fieldType() string {
+	switch {
+	case f.multi():
+		return "[]" + f.typ
+	case f.ptr():
+		return "*" + f.typ
+	}
+	return f.typ
+}
+
+func (f field) setter() string {
+	if f.base {
+		return "dia.Set" + f.name
+	}
+	return "set" + f.name
+}
+
+func (f field) getter() string {
+	if f.base {
+		return "dia.Get" + f.name
+	}
+	return "get" + f.name
+}
+
+// hasFlag is true when presence of the required AVP can't be
+// detected from the value
+func (f field) hasFlag() bool {
+	return !f.multi() && f.rule.Min != 0 && len(emptyCond(f.typ, "")) == 0
+}
+
+// emptyCond returns condition that the value x is empty
+func emptyCond(typ, x string) string {
+	switch typ {
+	case "string", "[]byte", "dia.Identity", "[]dia.RawAVP":
+		return "len(" + x + ") == 0"
+	case "net.IP":
+		return x + " == nil"
+	case "time.Time":
+		return x + ".IsZero()"
+	}
+	return ""
+}
+
+// notEmptyCond returns condition that the value x is not empty
+func notEmptyCond(typ, x string) string {
+	switch typ {
+	case "string", "[]byte", "dia.Identity", "[]dia.RawAVP":
+		return "len(" + x + ") != 0"
+	case "net.IP":
+		return x + " != nil"
+	case "time.Time":
+		return "!" + x + ".IsZero()"
+	}
+	return ""
+}
+
+// baseAVP is AVP that has set/get function in diameter package
+var baseAVP = map[string]bool{
+	"Origin-Host":       true,
+	"Origin-Realm":      true,
+	"Destination-Host":  true,
+	"Destination-Realm": true}
+
+type generator struct {
+	pkg    string
+	app    string
+	vendor uint32
+	files  []string
+
+	w         *bytes.Buffer
+	helpers   []dia.DictAVP
+	helperSet map[string]bool
+	useNet    bool
+	useTime   bool
+}
+
+func newGenerator(pkg, app string, vendor uint32, files []string) *generator {
+	return &generator{
+		pkg: pkg, app: app, vendor: vendor, files: files,
+		w:         new(bytes.Buffer),
+		helperSet: make(map[string]bool)}
+}
+
+func (g *generator) printf(format string, a ...interface{}) {
+	fmt.Fprintf(g.w, format, a...)
+}
+
+// bytes returns generated source code
+func (g *generator) bytes() []byte {
+	w := new(bytes.Buffer)
+	fmt.Fprintf(w, "// Code generated by diagen from %s. DO NOT EDIT.\n\n",
+		strings.Join(g.files, ", "))
+	fmt.Fprintf(w, "package %s\n\n", g.pkg)
+	fmt.Fprintf(w, "import (\n\"bytes\"\n\"fmt\"\n")
+	if g.useNet {
+		fmt.Fprintf(w, "\"net\"\n")
+	}
+	if g.useTime {
+		fmt.Fprintf(w, "\"time\"\n")
+	}
+	fmt.Fprintf(w, "\ndia \"github.com/fkgi/diameter\"\n)\n")
+	w.Write(g.w.Bytes())
+	return w.Bytes()
+}
+
+func (g *generator) goType(d dia.DictAVP) string {
+	switch d.Type {
+	case "OctetString":
+		return "[]byte"
+	case "UTF8String", "QoSFilterRule":
+		return "string"
+	case "IPFilterRule":
+		return "dia.IPFilterRule"
+	case "DiameterIdentity":
+		return "dia.Identity"
+	case "DiameterURI":
+		return "dia.URI"
+	case "Address":
+		g.useNet = true
+		return "net.IP"
+	case "Time":
+		g.useTime = true
+		return "time.Time"
+	case "Integer32":
+		return "int32"
+	case "Integer64":
+		return "int64"
+	case "Unsigned32":
+		return "uint32"
+	case "Unsigned64":
+		return "uint64"
+	case "Float32":
+		return "float32"
+	case "Float64":
+		return "float64"
+	case "Enumerated":
+		if len(d.Enum) != 0 {
+			return goName(d.Name)
+		}
+		return "dia.Enumerated"
+	case "Grouped":
+		if len(d.Group) != 0 {
+			return goName(d.Name)
+		}
+		return "[]dia.RawAVP"
+	}
+	return "[]byte"
+}
+
+// field returns field of the rule and add set/get functions of the AVP
+func (g *generator) field(r dia.DictRule, base bool) (field, error) {
+	d, ok := dia.LookupAVPByName(r.Name)
+	if !ok {
+		return field{}, fmt.Errorf("AVP %s is not defined in dictionary", r.Name)
+	}
+	f := field{name: goName(d.Name), avp: d, rule: r, typ: g.goType(d)}
+	if base && baseAVP[d.Name] {
+		f.base = true
+	} else if !g.helperSet[d.Name] {
+		g.helperSet[d.Name] = true
+		g.helpers = append(g.helpers, d)
+	}
+	return f, nil
+}
+
+func (g *generator) generate(cmds []command) error {
+	var regs []string
+	for _, req := range cmds {
+		if !req.req {
+			continue
+		}
+		ans, ok := command{}, false
+		for _, c := range cmds {
+			if !c.req && c.code == req.code && c.appID == req.appID {
+				ans, ok = c, true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("no answer of %s", req.name)
+		}
+		if e := g.genMessage(req, ans); e != nil {
+			return e
+		}
+		if e := g.genMessage(ans, req); e != nil {
+			return e
+		}
+		regs = append(regs, fmt.Sprintf(
+			"dia.AddSupportedMessage(%d, %d, %d, %s{}, %s{})",
+			g.vendor, req.appID, req.code, req.name, ans.name))
+	}
+	if len(regs) == 0 {
+		return fmt.Errorf("no request definition")
+	}
+
+	for i := 0; i < len(g.helpers); i++ {
+		if e := g.genAVP(g.helpers[i]); e != nil {
+			return e
+		}
+	}
+
+	name := g.app
+	if len(name) == 0 {
+		if a, ok := dia.LookupApplication(cmds[0].appID); ok {
+			name = a.Name
+		} else {
+			name = fmt.Sprintf("App%d", cmds[0].appID)
+		}
+	}
+	name = goName(name)
+	g.printf("\n// Register%s add messages of %s to supported messages\n", name, name)
+	g.printf("func Register%s() {\n%s\n}\n", name, strings.Join(regs, "\n"))
+	return nil
+}
+
+// genMessage generates message struct and methods.
+// pair is request of the answer or answer of the request.
+func (g *generator) genMessage(c, pair command) error {
+	has := make(map[string]bool)
+	var fields []field
+	codes := make(map[uint32]string)
+	for _, r := range c.rules {
+		has[r.Name] = true
+		switch r.Name {
+		case "Session-Id", "DRMP", "Vendor-Specific-Application-Id",
+			"Auth-Session-State", "Result-Code", "Experimental-Result",
+			"Failed-AVP", "Route-Record", "AVP":
+			continue
+		}
+		f, e := g.field(r, true)
+		if e != nil {
+			return fmt.Errorf("%s: %s", c.name, e)
+		}
+		if n, ok := codes[f.avp.Code]; ok {
+			return fmt.Errorf("%s: AVP code %d of %s is used by %s",
+				c.name, f.avp.Code, f.avp.Name, n)
+		}
+		codes[f.avp.Code] = f.avp.Name
+		fields = append(fields, f)
+	}
+	result := has["Result-Code"] || has["Experimental-Result"]
+
+	desc := fmt.Sprintf("command %d", c.code)
+	if d, ok := dia.LookupCommand(c.appID, c.code); ok {
+		desc = goName(d.Name)
+	}
+	if c.req {
+		desc += "Request"
+	} else {
+		desc += "Answer"
+	}
+	g.printf("\n/*\n%s is %s message.\n", c.name, desc)
+	for _, l := range strings.Split(c.abnf, "\n") {
+		g.printf(" %s\n", strings.TrimRight(l, " \t\r"))
+	}
+	g.printf("*/\ntype %s struct {\n", c.name)
+	if has["DRMP"] {
+		g.printf("dia.DRMP\n")
+	}
+	if result {
+		g.printf("ResultCode uint32\n")
+	}
+	for _, f := range fields {
+		g.printf("%s %s\n", f.name, f.fieldType())
+	}
+	if has["Failed-AVP"] {
+		g.printf("\nFailedAVP []dia.RawAVP\n")
+	}
+	g.printf("\nAVP []dia.RawAVP\n}\n")
+
+	// String
+	width := len("Exp-Result-Code")
+	for _, f := range fields {
+		if len(f.avp.Name) > width {
+			width = len(f.avp.Name)
+		}
+	}
+	label := func(s string) string {
+		return fmt.Sprintf("%s%-*s =", "%s", width, s)
+	}
+	g.printf("\nfunc (v %s) String() string {\nw := new(bytes.Buffer)\n\n", c.name)
+	if has["DRMP"] {
+		g.printf("if v.DRMP != dia.UnknownPriority {\n")
+		g.printf("fmt.Fprintf(w, \"%s%%s\\n\", dia.Indent, v.DRMP)\n}\n", label("DRMP"))
+	}
+	if result {
+		g.printf("if v.ResultCode > 10000 {\n")
+		g.printf("fmt.Fprintf(w, \"%s%%d:%%d\\n\", dia.Indent, v.ResultCode/10000, v.ResultCode%%10000)\n",
+			label("Exp-Result-Code"))
+		g.printf("} else {\n")
+		g.printf("fmt.Fprintf(w, \"%s%%d\\n\", dia.Indent, v.ResultCode)\n}\n",
+			label("Result-Code"))
+	}
+	for _, f := range fields {
+		verb := "%v"
+		if f.typ == "[]byte" {
+			verb = "%x"
+		}
+		if f.ptr() {
+			g.printf("if v.%s != nil {\n", f.name)
+			g.printf("fmt.Fprintf(w, \"%s%s\\n\", dia.Indent, *v.%s)\n}\n",
+				label(f.avp.Name), verb, f.name)
+		} else {
+			g.printf("fmt.Fprintf(w, \"%s%s\\n\", dia.Indent, v.%s)\n",
+				label(f.avp.Name), verb, f.name)
+		}
+	}
+	g.printf("\nreturn w.String()\n}\n")
+
+	// ToRaw
+	g.printf("\n// ToRaw return dia.RawMsg struct of this value\n")
+	g.printf("func (v %s) ToRaw(s string) dia.RawMsg {\n", c.name)
+	g.printf("m := dia.RawMsg{\nVer:  dia.DiaVer,\n")
+	g.printf("FlgR: %t, FlgP: %t, FlgE: false, FlgT: false,\n", c.req, c.pxy)
+	g.printf("Code: %d, AppID: %d,\n", c.code, c.appID)
+	g.printf("AVP: make([]dia.RawAVP, 0, %d)}\n\n", len(c.rules))
+	fi := 0
+	resultDone := false
+	for _, r := range c.rules {
+		switch r.Name {
+		case "Session-Id":
+			g.printf("m.AVP = append(m.AVP, dia.SetSessionID(s))\n")
+		case "DRMP":
+			g.printf("if v.DRMP != dia.UnknownPriority {\n")
+			g.printf("m.AVP = append(m.AVP, dia.SetDRMP(v.DRMP))\n}\n")
+		case "Vendor-Specific-Application-Id":
+			g.printf("m.AVP = append(m.AVP, dia.SetVendorSpecAppID(%d, m.AppID))\n", g.vendor)
+		case "Auth-Session-State":
+			g.printf("m.AVP = append(m.AVP, dia.SetAuthSessionState(false))\n")
+		case "Result-Code", "Experimental-Result":
+			if !resultDone {
+				g.printf("m.AVP = append(m.AVP, dia.SetResultCode(v.ResultCode))\n")
+				resultDone = true
+			}
+		case "Failed-AVP":
+			g.printf("if v.ResultCode != dia.DiameterSuccess && len(v.FailedAVP) != 0 {\n")
+			g.printf("m.AVP = append(m.AVP, dia.SetFailedAVP(v.FailedAVP))\n}\n")
+		case "Route-Record":
+			if c.req {
+				if has["Origin-Host"] {
+					g.printf("m.AVP = append(m.AVP, dia.SetRouteRecord(v.OriginHost))\n")
+				} else {
+					g.printf("m.AVP = append(m.AVP, dia.SetRouteRecord(dia.Host))\n")
+				}
+			}
+		case "AVP":
+			g.printf("m.AVP = append(m.AVP, v.AVP...)\n")
+		default:
+			g.printf("%s", appendField(fields[fi], "m.AVP"))
+			fi++
+		}
+	}
+	if !has["AVP"] {
+		g.printf("m.AVP = append(m.AVP, v.AVP...)\n")
+	}
+	g.printf("return m\n}\n")
+
+	// FromRaw
+	g.printf("\n// FromRaw make this value from dia.RawMsg struct\n")
+	if c.req {
+		g.printf("func (%s) FromRaw(m dia.RawMsg) (dia.Request, string, error) {\n", c.name)
+	} else {
+		g.printf("func (%s) FromRaw(m dia.RawMsg) (dia.Answer, string, error) {\n", c.name)
+	}
+	g.printf("s := \"\"\ne := m.Validate(%t, %t, false, false)\n", c.req, c.pxy)
+	g.printf("if e != nil {\nreturn nil, s, e\n}\n\n")
+	g.printf("v := %s{}\n", c.name)
+	g.printf("%s", hasFlags(fields))
+	g.printf("for _, a := range m.AVP {\nswitch a.Code {\n")
+	if has["Session-Id"] {
+		g.printf("case 263:\ns, e = dia.GetSessionID(a)\n")
+	}
+	if has["DRMP"] {
+		g.printf("case 301:\nv.DRMP, e = dia.GetDRMP(a)\n")
+	}
+	if result {
+		g.printf("case 268, 297:\nv.ResultCode, e = dia.GetResultCode(a)\n")
+	}
+	if has["Failed-AVP"] {
+		g.printf("case 279:\nv.FailedAVP, e = dia.GetFailedAVP(a)\n")
+	}
+	for _, f := range fields {
+		g.printf("%s", caseField(f))
+	}
+	var skip []string
+	for _, s := range []struct {
+		name string
+		code string
+	}{{"Vendor-Specific-Application-Id", "260"},
+		{"Auth-Session-State", "277"}, {"Route-Record", "282"}} {
+		if has[s.name] {
+			skip = append(skip, s.code)
+		}
+	}
+	if len(skip) != 0 {
+		g.printf("case %s:\n", strings.Join(skip, ", "))
+	}
+	g.printf("default:\nv.AVP = append(v.AVP, a)\n}\n\n")
+	g.printf("if e != nil {\nreturn nil, s, e\n}\n}\n\n")
+	conds := missingConds(fields)
+	if result {
+		conds = append([]string{"v.ResultCode == 0"}, conds...)
+	}
+	if len(conds) != 0 {
+		g.printf("if %s {\n", strings.Join(conds, " ||\n"))
+		g.printf("e = dia.InvalidAVP(dia.DiameterMissingAvp)\n}\n")
+	}
+	g.printf("return v, s, e\n}\n")
+
+	if c.req {
+		// Failed
+		ph := make(map[string]bool)
+		for _, r := range pair.rules {
+			ph[r.Name] = true
+		}
+		g.printf("\n// Failed make error message for timeout\n")
+		g.printf("func (v %s) Failed(c uint32) dia.Answer {\nreturn %s{\n", c.name, pair.name)
+		if has["DRMP"] && ph["DRMP"] {
+			g.printf("DRMP: v.DRMP,\n")
+		}
+		g.printf("ResultCode: c,\n")
+		if ph["Origin-Host"] {
+			g.printf("OriginHost: dia.Host,\n")
+		}
+		if ph["Origin-Realm"] {
+			g.printf("OriginRealm: dia.Realm,\n")
+		}
+		g.printf("}\n}\n")
+	} else {
+		if !result {
+			return fmt.Errorf("%s: no Result-Code in answer", c.name)
+		}
+		g.printf("\n// Result returns result-code\n")
+		g.printf("func (v %s) Result() uint32 {\nreturn v.ResultCode\n}\n", c.name)
+	}
+
+	kind := "Answer"
+	if c.req {
+		kind = "Request"
+	}
+	g.printf("\n// MarshalJSON returns JSON of the message in format of dia.RawMsg\n")
+	g.printf("// with empty Session-Id. Use dia.MarshalMessage to set Session-Id.\n")
+	g.printf("func (v %s) MarshalJSON() ([]byte, error) {\nreturn dia.MarshalMessage(v, \"\")\n}\n", c.name)
+	g.printf("\n// UnmarshalJSON decodes JSON in format of dia.RawMsg\n")
+	g.printf("func (v *%s) UnmarshalJSON(b []byte) error {\n", c.name)
+	g.printf("r, _, e := dia.Unmarshal%s(b, %s{})\nif e == nil {\n*v = r.(%s)\n}\nreturn e\n}\n",
+		kind, c.name, c.name)
+	return nil
+}
+
+// genAVP generates set/get functions and value type of the AVP
+func (g *generator) genAVP(d dia.DictAVP) error {
+	name := goName(d.Name)
+	typ := g.goType(d)
+
+	var bits []string
+	for _, b := range []struct {
+		r dia.FlagRule
+		f string
+	}{{d.VendorBit, "FlgV"}, {d.Mandatory, "FlgM"}, {d.Protected, "FlgP"}} {
+		switch b.r {
+		case dia.FlagMust:
+			bits = append(bits, "!a."+b.f)
+		case dia.FlagMustNot:
+			bits = append(bits, "a."+b.f)
+		}
+	}
+	header := fmt.Sprintf(
+		"a = dia.RawAVP{Code: %d, VenID: %d, FlgV: %t, FlgM: %t, FlgP: %t}\n",
+		d.Code, d.VenID, d.VendorBit == dia.FlagMust,
+		d.Mandatory == dia.FlagMust, d.Protected == dia.FlagMust)
+	// bitCheck prints flag check and the decode statement
+	bitCheck := func(decode string, then string) {
+		if len(bits) != 0 {
+			g.printf("if %s {\n", strings.Join(bits, " || "))
+			g.printf("e = dia.InvalidAVP(dia.DiameterInvalidAvpBits)\n} else ")
+		}
+		switch {
+		case len(then) != 0:
+			g.printf("if e = %s; e == nil {\n%s}\n", decode, then)
+		case len(bits) != 0:
+			g.printf("{\ne = %s\n}\n", decode)
+		default:
+			g.printf("e = %s\n", decode)
+		}
+	}
+
+	switch {
+	case d.Type == "Enumerated" && len(d.Enum) != 0:
+		g.printf("\n// %s is value of %s AVP\ntype %s dia.Enumerated\n\n", name, d.Name, name)
+		keys := make([]int64, 0, len(d.Enum))
+		for k := range d.Enum {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+		used := make(map[string]bool)
+		var cases []string
+		g.printf("const (\n")
+		for _, k := range keys {
+			c := name + enumName(d.Enum[k])
+			if used[c] {
+				c = fmt.Sprintf("%s%d", c, k)
+			}
+			used[c] = true
+			g.printf("// %s is %s\n%s %s = %d\n", c, d.Enum[k], c, name, k)
+			cases = append(cases, fmt.Sprintf("case %s:\nreturn %q\n", c, d.Enum[k]))
+		}
+		g.printf(")\n\n")
+		g.printf("func (v %s) String() string {\nswitch v {\n%s}\n", name, strings.Join(cases, ""))
+		g.printf("return fmt.Sprintf(\"%%d\", v)\n}\n")
+
+		g.printf("\nfunc set%s(v %s) (a dia.RawAVP) {\n%s", name, name, header)
+		g.printf("a.Encode(dia.Enumerated(v))\nreturn\n}\n")
+		g.printf("\nfunc get%s(a dia.RawAVP) (v %s, e error) {\n", name, name)
+		g.printf("s := new(dia.Enumerated)\n")
+		bitCheck("a.Decode(s)", fmt.Sprintf("v = %s(*s)\n", name))
+		g.printf("return\n}\n")
+
+	case d.Type == "Grouped" && len(d.Group) != 0:
+		var fields []field
+		codes := make(map[uint32]string)
+		wildcard := false
+		for _, r := range d.Group {
+			if r.Name == "AVP" {
+				wildcard = true
+				continue
+			}
+			f, e := g.field(r, false)
+			if e != nil {
+				return fmt.Errorf("%s: %s", d.Name, e)
+			}
+			if n, ok := codes[f.avp.Code]; ok {
+				return fmt.Errorf("%s: AVP code %d of %s is used by %s",
+					d.Name, f.avp.Code, f.avp.Name, n)
+			}
+			codes[f.avp.Code] = f.avp.Name
+			fields = append(fields, f)
+		}
+
+		g.printf("\n// %s is value of %s AVP\ntype %s struct {\n", name, d.Name, name)
+		for _, f := range fields {
+			g.printf("%s %s\n", f.name, f.fieldType())
+		}
+		if wildcard {
+			g.printf("\nAVP []dia.RawAVP\n")
+		}
+		g.printf("}\n")
+
+		g.printf("\nfunc set%s(v %s) (a dia.RawAVP) {\n%s", name, name, header)
+		g.printf("o := []dia.RawAVP{}\n")
+		for _, f := range fields {
+			g.printf("%s", appendField(f, "o"))
+		}
+		if wildcard {
+			g.printf("o = append(o, v.AVP...)\n")
+		}
+		g.printf("a.Encode(o)\nreturn\n}\n")
+
+		g.printf("\nfunc get%s(a dia.RawAVP) (v %s, e error) {\n", name, name)
+		g.printf("o := []dia.RawAVP{}\n")
+		bitCheck("a.Decode(&o)", "")
+		g.printf("%s", hasFlags(fields))
+		g.printf("for _, a := range o {\nswitch a.Code {\n")
+		for _, f := range fields {
+			g.printf("%s", caseField(f))
+		}
+		if wildcard {
+			g.printf("default:\nv.AVP = append(v.AVP, a)\n")
+		}
+		g.printf("}\nif e != nil {\nreturn\n}\n}\n")
+		if conds := missingConds(fields); len(conds) != 0 {
+			g.printf("if e == nil && (%s) {\n", strings.Join(conds, " ||\n"))
+			g.printf("e = dia.InvalidAVP(dia.DiameterMissingAvp)\n}\n")
+		}
+		g.printf("return\n}\n")
+
+	default:
+		g.printf("\nfunc set%s(v %s) (a dia.RawAVP) {\n%s", name, typ, header)
+		g.printf("a.Encode(v)\nreturn\n}\n")
+		g.printf("\nfunc get%s(a dia.RawAVP) (v %s, e error) {\n", name, typ)
+		bitCheck("a.Decode(&v)", "")
+		g.printf("return\n}\n")
+	}
+	return nil
+}
+
+// appendField returns code that appends AVP of the field to dst
+func appendField(f field, dst string) string {
+	v := "v." + f.name
+	switch {
+	case f.multi():
+		return fmt.Sprintf("for _, t := range %s {\n%s = append(%s, %s(t))\n}\n",
+			v, dst, dst, f.setter())
+	case f.ptr():
+		return fmt.Sprintf("if %s != nil {\n%s = append(%s, %s(*%s))\n}\n",
+			v, dst, dst, f.setter(), v)
+	case f.rule.Min == 0:
+		return fmt.Sprintf("if %s {\n%s = append(%s, %s(%s))\n}\n",
+			notEmptyCond(f.typ, v), dst, dst, f.setter(), v)
+	}
+	return fmt.Sprintf("%s = append(%s, %s(%s))\n", dst, dst, f.setter(), v)
+}
+
+// caseField returns case clause that gets the field from AVP a
+func caseField(f field) string {
+	s := fmt.Sprintf("case %d:\n", f.avp.Code)
+	switch {
+	case f.multi():
+		s += fmt.Sprintf("var t %s\nif t, e = %s(a); e == nil {\nv.%s = append(v.%s, t)\n}\n",
+			f.typ, f.getter(), f.name, f.name)
+	case f.ptr():
+		s += fmt.Sprintf("var t %s\nif t, e = %s(a); e == nil {\nv.%s = &t\n}\n",
+			f.typ, f.getter(), f.name)
+	default:
+		s += fmt.Sprintf("v.%s, e = %s(a)\n", f.name, f.getter())
+	}
+	if f.hasFlag() {
+		s += fmt.Sprintf("has%s = true\n", f.name)
+	}
+	return s
+}
+
+// hasFlags returns declaration of presence flags of required fields
+func hasFlags(fields []field) string {
+	s := ""
+	for _, f := range fields {
+		if f.hasFlag() {
+			s += fmt.Sprintf("has%s := false\n", f.name)
+		}
+	}
+	return s
+}
+
+// missingConds returns conditions that required AVP is missing
+func missingConds(fields []field) []string {
+	var conds []string
+	for _, f := range fields {
+		switch {
+		case f.multi() && f.rule.Min > 0:
+			conds = append(conds, fmt.Sprintf("len(v.%s) < %d", f.name, f.rule.Min))
+		case f.multi() || f.rule.Min == 0:
+		case f.hasFlag():
+			conds = append(conds, "!has"+f.name)
+		default:
+			conds = append(conds, emptyCond(f.typ, "v."+f.name))
+		}
+	}
+	return conds
+}
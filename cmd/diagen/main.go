@@ -0,0 +1,202 @@
+/*
+Diagen generates Go message types of Diameter application
+from dictionary and command ABNF definitions.
+
+Usage:
+
+	diagen [flags] abnf-file...
+
+The flags are:
+
+	-dict file
+		additional dictionary file in Wireshark XML format.
+		Built-in dictionary of the diameter package is always loaded.
+	-pkg name
+		package name of generated file (default "main").
+	-app name
+		application name that is used for registration function name.
+	-vendor id
+		Vendor-Id of Vendor-Specific-Application-Id (default 10415).
+	-o file
+		output file (default standard output).
+
+ABNF file has command definitions of RFC 6733 format, for example
+
+	<ALR> ::= < Diameter Header: 8388648, REQ, PXY, 16777312 >
+	          < Session-Id >
+	          { Origin-Host }
+	          { SC-Address }
+	        * [ AVP ]
+
+Name in the header is used as Go type name.
+Session-Id, DRMP, Vendor-Specific-Application-Id, Auth-Session-State,
+Origin-Host, Origin-Realm, Destination-Host, Destination-Realm,
+Result-Code, Experimental-Result, Failed-AVP and Route-Record are handled
+with functions of the diameter package.
+Other AVPs are defined in dictionary and set/get functions are generated.
+
+It is used with go generate, for example
+
+	//go:generate diagen -pkg ts29338 -app S6c -o S6c_gen.go S6c.abnf
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	dia "github.com/fkgi/diameter"
+)
+
+type command struct {
+	name  string
+	code  uint32
+	appID uint32
+	req   bool
+	pxy   bool
+	abnf  string
+	rules dia.Grammar
+}
+
+var (
+	defRegexp    = regexp.MustCompile(`<\s*([A-Za-z][A-Za-z0-9_-]*)\s*>\s*::=`)
+	headerRegexp = regexp.MustCompile(`<\s*Diameter Header:([^>]*)>`)
+)
+
+func main() {
+	dict := flag.String("dict", "", "additional dictionary file")
+	pkg := flag.String("pkg", "main", "package name")
+	app := flag.String("app", "", "application name")
+	vendor := flag.Uint("vendor", 10415, "Vendor-Id of Vendor-Specific-Application-Id")
+	out := flag.String("o", "", "output file")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("diagen: ")
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if len(*dict) != 0 {
+		if e := dia.LoadDictionaryFile(*dict); e != nil {
+			log.Fatalln(e)
+		}
+	}
+
+	var cmds []command
+	for _, f := range flag.Args() {
+		b, e := os.ReadFile(f)
+		if e != nil {
+			log.Fatalln(e)
+		}
+		c, e := parseABNF(string(b))
+		if e != nil {
+			log.Fatalf("%s: %s", f, e)
+		}
+		cmds = append(cmds, c...)
+	}
+	if len(cmds) == 0 {
+		log.Fatalln("no command definition")
+	}
+
+	g := newGenerator(*pkg, *app, uint32(*vendor), flag.Args())
+	if e := g.generate(cmds); e != nil {
+		log.Fatalln(e)
+	}
+	src, e := format.Source(g.bytes())
+	if e != nil {
+		log.Fatalf("invalid generated code: %s", e)
+	}
+
+	if len(*out) == 0 {
+		_, e = os.Stdout.Write(src)
+	} else {
+		e = os.WriteFile(*out, src, 0644)
+	}
+	if e != nil {
+		log.Fatalln(e)
+	}
+}
+
+// parseABNF returns commands in the text
+func parseABNF(s string) ([]command, error) {
+	idx := defRegexp.FindAllStringSubmatchIndex(s, -1)
+	cmds := make([]command, 0, len(idx))
+	for i, m := range idx {
+		end := len(s)
+		if i+1 < len(idx) {
+			end = idx[i+1][0]
+		}
+		c := command{
+			name: s[m[2]:m[3]],
+			abnf: strings.TrimSpace(s[m[0]:end])}
+
+		h := headerRegexp.FindStringSubmatch(c.abnf)
+		if h == nil {
+			return nil, fmt.Errorf("no Diameter Header in %s", c.name)
+		}
+		hs := strings.Split(h[1], ",")
+		if len(hs) < 2 {
+			return nil, fmt.Errorf("invalid Diameter Header in %s", c.name)
+		}
+		v, e := strconv.ParseUint(strings.TrimSpace(hs[0]), 10, 32)
+		if e != nil {
+			return nil, fmt.Errorf("invalid command code in %s", c.name)
+		}
+		c.code = uint32(v)
+		for _, f := range hs[1:] {
+			switch f = strings.TrimSpace(f); f {
+			case "REQ":
+				c.req = true
+			case "PXY":
+				c.pxy = true
+			case "ERR":
+			default:
+				if v, e = strconv.ParseUint(f, 10, 32); e != nil {
+					return nil, fmt.Errorf("invalid header flag %s in %s", f, c.name)
+				}
+				c.appID = uint32(v)
+			}
+		}
+
+		if c.rules, e = dia.ParseGrammar(c.abnf); e != nil {
+			return nil, fmt.Errorf("%s: %s", c.name, e)
+		}
+		cmds = append(cmds, c)
+	}
+	return cmds, nil
+}
+
+// goName returns Go identifier of the AVP or application name
+func goName(s string) string {
+	var b strings.Builder
+	for _, w := range strings.FieldsFunc(s, isSeparator) {
+		if w == "Id" {
+			w = "ID"
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	if n := b.String(); len(n) == 0 || (n[0] >= '0' && n[0] <= '9') {
+		return "AVP" + n
+	}
+	return b.String()
+}
+
+// enumName returns Go identifier of the enumerated value label
+func enumName(s string) string {
+	var b strings.Builder
+	for _, w := range strings.FieldsFunc(s, isSeparator) {
+		b.WriteString(strings.ToUpper(w[:1]) + strings.ToLower(w[1:]))
+	}
+	return b.String()
+}
+
+func isSeparator(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+}
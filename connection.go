@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,14 +28,31 @@ type Conn struct {
 	wdCount int         // watchdog expired counter
 
 	notify chan stateEvent
+	done   chan struct{} // closed when event handler is stopped
 	state
+	current  int32 // copy of state for reading from other goroutine
 	con      net.Conn
 	sndstack map[uint32]chan RawMsg
 	rcvstack chan RawMsg
+	sndqueue sndQueue
+
+	rxWindow time.Time // start time of Rx rate window
+	rxCount  int       // recieved request count in Rx rate window
+	shedding int32     // inbound request is shed or not
+	brk      breaker   // outbound circuit breaker
+	load     *Load     // latest PEER type load reported by the peer
+	inflight int32     // recieved request that is not answered yet
+	cause    Enumerated
+
+	statMutex sync.Mutex
+	stats     map[CounterKey]uint64
+	latency   map[LatencyKey]*Histogram
 
 	Since        time.Time
 	RxReq        uint64
 	Reject       uint64
+	RxBusy       uint64
+	TxReqReject  uint64
 	Tx1xxx       uint64
 	Tx2xxx       uint64
 	Tx3xxx       uint64
@@ -57,9 +76,26 @@ func (c *Conn) String() string {
 	fmt.Fprintf(w, "%sPeer          =%s\n", Indent, c.Peer)
 	fmt.Fprintf(w, "%sUptime        =%s\n",
 		Indent, time.Now().Sub(c.Since).String())
-	fmt.Fprintf(w, "%sRxReqCount    =%d\n", Indent, c.RxReq)
-	fmt.Fprintf(w, "%sRejectCount   =%d\n", Indent, c.Reject)
-	fmt.Fprintf(w, "%sTx1xxxCount   =%d\n", Indent, c.Tx1xxx)
+	fmt.Fprintf(w, "%sRxReqCount    =%d\n", Indent, atomic.LoadUint64(&c.RxReq))
+	fmt.Fprintf(w, "%sRejectCount   =%d\n", Indent, atomic.LoadUint64(&c.Reject))
+	fmt.Fprintf(w, "%sRxBusyCount   =%d\n", Indent, atomic.LoadUint64(&c.RxBusy))
+	fmt.Fprintf(w, "%sTx1xxxCount   =%d\n", Indent, atomic.LoadUint64(&c.Tx1xxx))
+	fmt.Fprintf(w, "%sTx2xxxCount   =%d\n", Indent, atomic.LoadUint64(&c.Tx2xxx))
+	fmt.Fprintf(w, "%sTx3xxxCount   =%d\n", Indent, atomic.LoadUint64(&c.Tx3xxx))
+	fmt.Fprintf(w, "%sTx4xxxCount   =%d\n", Indent, atomic.LoadUint64(&c.Tx4xxx))
+	fmt.Fprintf(w, "%sTx5xxxCount   =%d\n", Indent, atomic.LoadUint64(&c.Tx5xxx))
+	fmt.Fprintf(w, "%sTxEtcCount    =%d\n", Indent, atomic.LoadUint64(&c.TxEtc))
+	fmt.Fprintf(w, "%sTxReqCount    =%d\n", Indent, atomic.LoadUint64(&c.TxReq))
+	fmt.Fprintf(w, "%sTxFailCount   =%d\n", Indent, atomic.LoadUint64(&c.TxReqFail))
+	fmt.Fprintf(w, "%sTxTimeout     =%d\n", Indent, atomic.LoadUint64(&c.TxReqTimeout))
+	fmt.Fprintf(w, "%sTxRejectCount =%d\n", Indent, atomic.LoadUint64(&c.TxReqReject))
+	fmt.Fprintf(w, "%sRx1xxxCount   =%d\n", Indent, atomic.LoadUint64(&c.Rx1xxx))
+	fmt.Fprintf(w, "%sRx2xxxCount   =%d\n", Indent, atomic.LoadUint64(&c.Rx2xxx))
+	fmt.Fprintf(w, "%sRx3xxxCount   =%d\n", Indent, atomic.LoadUint64(&c.Rx3xxx))
+	fmt.Fprintf(w, "%sRx4xxxCount   =%d\n", Indent, atomic.LoadUint64(&c.Rx4xxx))
+	fmt.Fprintf(w, "%sRx5xxxCount   =%d\n", Indent, atomic.LoadUint64(&c.Rx5xxx))
+	fmt.Fprintf(w, "%sRxEtcCount    =%d\n", Indent, atomic.LoadUint64(&c.RxEtc))
+	fmt.Fprintf(w, "%sBreaker       =%s\n", Indent, c.Breaker())
 
 	return w.String()
 }
@@ -76,7 +112,7 @@ func (c *Conn) TxQueue() int {
 
 // Dial make new Conn that use specified peernode and connection
 func Dial(p Peer, c net.Conn, d time.Duration) (*Conn, error) {
-	if c == nil {
+	if c == nil || atomic.LoadInt32(&shuttingDown) != 0 {
 		return nil, ConnectionRefused{}
 	}
 	if len(p.Host) == 0 {
@@ -100,14 +136,17 @@ func Dial(p Peer, c net.Conn, d time.Duration) (*Conn, error) {
 	con := &Conn{
 		Peer:     &p,
 		notify:   make(chan stateEvent),
+		done:     make(chan struct{}),
 		state:    closed,
+		current:  int32(closed),
 		con:      c,
 		sndstack: make(map[uint32]chan RawMsg, TxBuffer),
 		rcvstack: make(chan RawMsg, RxBuffer)}
+	register(con)
 	go socketHandler(con)
-	Notify(StateUpdate{
-		oldStat: shutdown, newStat: con.state,
-		stateEvent: eventInit{}, conn: con, Err: nil})
+	publish(StateUpdate{
+		OldState: shutdown, NewState: con.state,
+		Event: eventInit{}.String(), Conn: con, Err: nil})
 	go eventHandler(con)
 
 	cer := MakeCER(con)
@@ -123,7 +162,7 @@ func Dial(p Peer, c net.Conn, d time.Duration) (*Conn, error) {
 		m := cer.Failed(DiameterTooBusy).ToRaw("")
 		m.HbHID = req.HbHID
 		m.EtEID = req.EtEID
-		con.notify <- eventRcvCEA{m}
+		con.post(eventRcvCEA{m})
 	})
 
 	ack := <-ch
@@ -140,25 +179,33 @@ func Accept(p *Peer, c net.Conn) (*Conn, error) {
 	if c == nil {
 		return nil, ConnectionRefused{}
 	}
+	if atomic.LoadInt32(&shuttingDown) != 0 {
+		c.Close()
+		return nil, ConnectionRefused{}
+	}
 	con := &Conn{
 		Peer:     p,
 		notify:   make(chan stateEvent),
+		done:     make(chan struct{}),
 		state:    waitCER,
+		current:  int32(waitCER),
 		con:      c,
 		sndstack: make(map[uint32]chan RawMsg, TxBuffer),
 		rcvstack: make(chan RawMsg, RxBuffer)}
+	register(con)
 	go socketHandler(con)
 
-	Notify(StateUpdate{
-		oldStat: shutdown, newStat: con.state,
-		stateEvent: eventInit{}, conn: con, Err: nil})
+	publish(StateUpdate{
+		OldState: shutdown, NewState: con.state,
+		Event: eventInit{}.String(), Conn: con, Err: nil})
 
 	event := <-con.notify
 	old := con.state
 	e := event.exec(con)
-	Notify(StateUpdate{
-		oldStat: old, newStat: con.state,
-		stateEvent: event, conn: con, Err: e})
+	atomic.StoreInt32(&con.current, int32(con.state))
+	publish(StateUpdate{
+		OldState: old, NewState: con.state,
+		Event: event.String(), Conn: con, Err: e})
 	if e != nil {
 		c.Close()
 	}
@@ -174,6 +221,9 @@ func socketHandler(c *Conn) {
 		if _, e := m.ReadFrom(c.con); e != nil {
 			break
 		}
+		if !m.FlgR {
+			c.count(false, m)
+		}
 
 		if m.AppID == 0 && m.Code == 257 && m.FlgR {
 			c.notify <- eventRcvCER{m}
@@ -199,15 +249,30 @@ func eventHandler(c *Conn) {
 		event := <-c.notify
 		old := c.state
 		e := event.exec(c)
+		atomic.StoreInt32(&c.current, int32(c.state))
 
-		Notify(StateUpdate{
-			oldStat: old, newStat: c.state,
-			stateEvent: event, conn: c, Err: e})
+		publish(StateUpdate{
+			OldState: old, NewState: c.state,
+			Event: event.String(), Conn: c, Err: e})
 
 		if _, ok := event.(eventPeerDisc); ok {
 			break
 		}
 	}
+	close(c.done)
+	unregister(c)
+	unsubscribe(func(s *subscription) bool { return s.conn == c })
+}
+
+// post send the event to event handler.
+// It returns false when event handler is already stopped.
+func (c *Conn) post(ev stateEvent) bool {
+	select {
+	case c.notify <- ev:
+		return true
+	case <-c.done:
+		return false
+	}
 }
 
 // NewSession make new session
@@ -222,60 +287,127 @@ func (c *Conn) NewSession() *Session {
 
 // Send Diameter request
 func (c *Conn) Send(m Request, d time.Duration) Answer {
+	a, _ := c.SendMsg(m, d)
+	return a
+}
+
+// SendMsg is same as Send, and also returns error of the recieved answer,
+// such as UnsupportedAVP when RejectUnsupportedAVP is enabled and
+// the answer has unrecognised AVP with M-bit, or GrammarError when
+// ValidateGrammar is enabled and the success answer doesn't match
+// with command grammar.
+// The recieved answer is returned with the error.
+func (c *Conn) SendMsg(m Request, d time.Duration) (Answer, error) {
 	sid := nextSession()
 	req := m.ToRaw(sid)
+	if OCFeatureVector != 0 {
+		if abate(c, req) {
+			return m.Failed(DiameterTooBusy), nil
+		}
+		setOCRequest(&req)
+	}
+	if !c.allow() {
+		atomic.AddUint64(&c.TxReqReject, 1)
+		return m.Failed(DiameterUnableToDeliver), nil
+	}
 	req.HbHID = nextHbH()
 	req.EtEID = nextEtE()
+	span := startSpan(c, SpanClient, &req)
 
 	ch := make(chan RawMsg)
 	c.sndstack[req.HbHID] = ch
-	c.notify <- eventSndMsg{m: req}
+	start := time.Now()
+	if !c.send(req, getPriority(req)) {
+		delete(c.sndstack, req.HbHID)
+		c.report(true)
+		atomic.AddUint64(&c.TxReqFail, 1)
+		endSpan(span, RawMsg{}, ConnectionRefused{})
+		return m.Failed(DiameterUnableToDeliver), nil
+	}
 
 	t := time.AfterFunc(d, func() {
 		m := m.Failed(DiameterTooBusy).ToRaw(sid)
 		m.HbHID = req.HbHID
 		m.EtEID = req.EtEID
-		c.notify <- eventRcvMsg{m}
+		c.post(eventRcvMsg{m})
 	})
 
 	a := <-ch
-	t.Stop()
+	timeout := !t.Stop()
+	c.report(a.Code == 0 || timeout || getResult(a) == DiameterTooBusy)
+	if timeout {
+		atomic.AddUint64(&c.TxReqTimeout, 1)
+		endSpan(span, RawMsg{}, AnswerTimeout{})
+	} else if a.Code == 0 {
+		endSpan(span, a, ConnectionRefused{})
+	} else {
+		endSpan(span, a, nil)
+	}
 	if a.Code == 0 {
-		return m.Failed(DiameterUnableToDeliver)
+		atomic.AddUint64(&c.TxReqFail, 1)
+		return m.Failed(DiameterUnableToDeliver), nil
+	}
+	if !timeout {
+		c.observe(req, a, time.Since(start))
+	}
+	if !timeout {
+		handleLoad(c, a)
+	}
+
+	var err error
+	if _, ok := supportedApps[a.AppID].ans[a.Code]; ok && RejectUnsupportedAVP && !a.FlgE {
+		err = CheckUnsupportedAVP(a)
+	}
+	if err == nil && ValidateGrammar && getResult(a) == DiameterSuccess {
+		err = CheckGrammar(a)
 	}
 
 	if app, ok := supportedApps[a.AppID]; !ok {
 	} else if ans, ok := app.ans[a.Code]; !ok {
 	} else if ack, _, e := ans.FromRaw(a); e == nil {
-		return ack
+		return ack, err
 	} else if avperr, ok := e.(InvalidAVP); ok {
-		return m.Failed(uint32(avperr))
+		return m.Failed(uint32(avperr)), e
 	} else {
-		return m.Failed(DiameterUnableToComply)
+		return m.Failed(DiameterUnableToComply), e
 	}
 
 	if app, ok := supportedApps[0xffffffff]; !ok {
 	} else if ans, ok := app.ans[0]; ok {
 		ack, _, _ := ans.FromRaw(a)
-		return ack
+		return ack, err
 	}
 
-	return m.Failed(DiameterUnableToComply)
+	return m.Failed(DiameterUnableToComply), nil
 }
 
 // Recieve Diameter request
 func (c *Conn) Recieve() (Request, func(Answer), error) {
+	r, f, _, e := c.RecieveMsg()
+	return r, f, e
+}
+
+// RecieveMsg is same as Recieve, and also returns true when the request
+// is potentially re-transmitted and may be a duplicate of the request
+// that is already handled by other node.
+// Duplicated request that is already handled by this node is not returned,
+// the answer is re-sent instead, or sent when the original request
+// is answered.
+func (c *Conn) RecieveMsg() (Request, func(Answer), bool, error) {
 	m := <-c.rcvstack
 	if m.Code == 0 {
 		c.rcvstack <- m
-		return nil, nil, ConnectionRefused{}
+		return nil, nil, false, ConnectionRefused{}
 	}
+	atomic.AddInt32(&c.inflight, 1)
+	span := startSpan(c, SpanServer, &m)
 
 	var req Request
 
 	if app, ok := supportedApps[m.AppID]; ok {
 		req, _ = app.req[m.Code]
 	}
+	registered := req != nil
 
 	if req == nil {
 		app, _ := supportedApps[0xffffffff]
@@ -283,21 +415,65 @@ func (c *Conn) Recieve() (Request, func(Answer), error) {
 	}
 
 	r, sid, e := req.FromRaw(m)
-	f := func(ans Answer) {
-		a := ans.ToRaw(sid)
+	if ValidateGrammar {
+		if ge := CheckGrammar(m); ge != nil {
+			e = ge
+		}
+	}
+	if registered && RejectUnsupportedAVP {
+		if ue := CheckUnsupportedAVP(m); ue != nil {
+			e = ue
+		}
+	}
+	reply := func(a RawMsg) {
+		defer atomic.AddInt32(&c.inflight, -1)
 		a.HbHID = m.HbHID
 		a.EtEID = m.EtEID
-		c.notify <- eventSndMsg{a}
+		setOCAnswer(c, m, &a)
+		setLoadAnswer(c, &a)
+		w := storeAnswer(m, a)
+		p := getPriority(m)
+		for _, avp := range a.AVP {
+			if avp.Code == 301 && avp.VenID == 0 {
+				p = getPriority(a)
+				break
+			}
+		}
+		if c.send(a, p) {
+			endSpan(span, a, nil)
+		} else {
+			endSpan(span, a, ConnectionRefused{})
+		}
+		replayAnswer(w, a, p)
+	}
+	f := func(ans Answer) {
+		reply(ans.ToRaw(sid))
 	}
 	if e != nil {
-		if avperr, ok := e.(InvalidAVP); ok {
-			f(req.Failed(uint32(avperr)))
-		} else {
-			f(req.Failed(DiameterUnableToComply))
+		if r != nil {
+			req = r
+		}
+		var a RawMsg
+		switch err := e.(type) {
+		case GrammarError:
+			a = req.Failed(err.Result).ToRaw(sid)
+			a.AVP = append(a.AVP, SetFailedAVP(err.FailedAVP()))
+		case UnsupportedAVP:
+			a = req.Failed(DiameterAvpUnsupported).ToRaw(sid)
+			a.AVP = append(a.AVP, SetFailedAVP(err.FailedAVP()))
+		case InvalidAVP:
+			a = req.Failed(uint32(err)).ToRaw(sid)
+		default:
+			a = req.Failed(DiameterUnableToComply).ToRaw(sid)
 		}
-		return r, nil, e
+		// req may be prototype of request when FromRaw failed
+		a.FlgP = m.FlgP
+		a.Code = m.Code
+		a.AppID = m.AppID
+		reply(a)
+		return r, nil, m.FlgT, e
 	}
-	return r, f, nil
+	return r, f, m.FlgT, nil
 }
 
 func (c *Conn) watchdog() {
@@ -308,13 +484,15 @@ func (c *Conn) watchdog() {
 
 	ch := make(chan RawMsg)
 	c.sndstack[req.HbHID] = ch
-	c.notify <- eventWatchdog{m: req}
+	if !c.post(eventWatchdog{m: req}) {
+		return
+	}
 
 	t := time.AfterFunc(c.Peer.WDInterval, func() {
 		m := dwr.Failed(DiameterTooBusy).ToRaw("")
 		m.HbHID = req.HbHID
 		m.EtEID = req.EtEID
-		c.notify <- eventRcvDWA{m}
+		c.post(eventRcvDWA{m})
 	})
 
 	<-ch
@@ -323,9 +501,15 @@ func (c *Conn) watchdog() {
 
 // Close stop state machine
 func (c *Conn) Close(d time.Duration) {
-	if c == nil || c.state != open {
+	c.CloseWithCause(Rebooting, d)
+}
+
+// CloseWithCause stop state machine with the Disconnect-Cause
+func (c *Conn) CloseWithCause(cause Enumerated, d time.Duration) {
+	if c == nil || c.getState() != open {
 		return
 	}
+	c.cause = cause
 
 	dpr := MakeDPR(c)
 	req := dpr.ToRaw("")
@@ -334,13 +518,15 @@ func (c *Conn) Close(d time.Duration) {
 
 	ch := make(chan RawMsg)
 	c.sndstack[req.HbHID] = ch
-	c.notify <- eventStop{m: req}
+	if !c.post(eventStop{m: req}) {
+		return
+	}
 
 	t := time.AfterFunc(d, func() {
 		m := dpr.Failed(DiameterTooBusy).ToRaw("")
 		m.HbHID = req.HbHID
 		m.EtEID = req.EtEID
-		c.notify <- eventRcvDPA{m}
+		c.post(eventRcvDPA{m})
 	})
 
 	<-ch
@@ -359,5 +545,10 @@ func (c *Conn) PeerAddr() net.Addr {
 
 // State returns state machine state
 func (c *Conn) State() string {
-	return c.state.String()
+	return c.getState().String()
+}
+
+// getState returns state of the state machine from outside of event handler
+func (c *Conn) getState() state {
+	return state(atomic.LoadInt32(&c.current))
 }
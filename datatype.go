@@ -0,0 +1,126 @@
+package diameter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Address family numbers of IANA
+const (
+	AddrIPv4       uint16 = 1
+	AddrIPv6       uint16 = 2
+	AddrNSAP       uint16 = 3
+	AddrHDLC       uint16 = 4
+	AddrBBN1822    uint16 = 5
+	Addr802        uint16 = 6
+	AddrE163       uint16 = 7
+	AddrE164       uint16 = 8
+	AddrF69        uint16 = 9
+	AddrX121       uint16 = 10
+	AddrIPX        uint16 = 11
+	AddrAppletalk  uint16 = 12
+	AddrDecnetIV   uint16 = 13
+	AddrBanyanVine uint16 = 14
+	AddrE164NSAP   uint16 = 15
+	AddrDNS        uint16 = 16
+)
+
+// Address is Address format AVP value with any address family.
+// IPv4 and IPv6 address can also be encoded and decoded as net.IP.
+type Address struct {
+	Family uint16
+	Value  []byte
+}
+
+// IPAddress returns Address of the IP address
+func IPAddress(ip net.IP) Address {
+	if v4 := ip.To4(); v4 != nil {
+		return Address{Family: AddrIPv4, Value: v4}
+	}
+	return Address{Family: AddrIPv6, Value: ip.To16()}
+}
+
+// IP returns net.IP of the address, or nil when it is not IP address
+func (a Address) IP() net.IP {
+	switch {
+	case a.Family == AddrIPv4 && len(a.Value) == net.IPv4len:
+		return net.IP(a.Value)
+	case a.Family == AddrIPv6 && len(a.Value) == net.IPv6len:
+		return net.IP(a.Value)
+	}
+	return nil
+}
+
+func (a Address) String() string {
+	switch a.Family {
+	case AddrIPv4, AddrIPv6:
+		if ip := a.IP(); ip != nil {
+			return ip.String()
+		}
+	case AddrE163, AddrE164, AddrDNS:
+		return string(a.Value)
+	case Addr802:
+		return net.HardwareAddr(a.Value).String()
+	}
+	return fmt.Sprintf("family=%d:% x", a.Family, a.Value)
+}
+
+func (a Address) bytes() ([]byte, error) {
+	switch {
+	case a.Family == AddrIPv4 && len(a.Value) != net.IPv4len:
+		return nil, fmt.Errorf("invalid IPv4 address length %d", len(a.Value))
+	case a.Family == AddrIPv6 && len(a.Value) != net.IPv6len:
+		return nil, fmt.Errorf("invalid IPv6 address length %d", len(a.Value))
+	}
+	b := make([]byte, 2, 2+len(a.Value))
+	binary.BigEndian.PutUint16(b, a.Family)
+	return append(b, a.Value...), nil
+}
+
+func parseAddress(b []byte) (a Address, e error) {
+	if len(b) < 2 {
+		return a, fmt.Errorf("no address family")
+	}
+	a.Family = binary.BigEndian.Uint16(b)
+	a.Value = make([]byte, len(b)-2)
+	copy(a.Value, b[2:])
+
+	switch {
+	case a.Family == AddrIPv4 && len(a.Value) != net.IPv4len:
+		e = fmt.Errorf("invalid IPv4 address length %d", len(a.Value))
+	case a.Family == AddrIPv6 && len(a.Value) != net.IPv6len:
+		e = fmt.Errorf("invalid IPv6 address length %d", len(a.Value))
+	}
+	return
+}
+
+/*
+Time is seconds since 0h on 1 January 1900 in 4 octets that is
+same as NTP timestamp of RFC 5905.
+The value rolls over in 2036, so the value that has most significant bit
+is interpreted as time from 1968 to 2036 and the value without it is
+interpreted as time from 2036 to 2104 (RFC 4330 section 3).
+*/
+const (
+	ntpOffset  int64 = 2208988800 // seconds from 1900 to 1970
+	ntpEraHalf int64 = 0x80000000
+	ntpEra     int64 = 0x100000000
+)
+
+func timeToNTP(t time.Time) (uint32, error) {
+	s := t.Unix() + ntpOffset
+	if s < ntpEraHalf || s >= ntpEra+ntpEraHalf {
+		return 0, fmt.Errorf("time %s is out of range", t)
+	}
+	return uint32(s), nil
+}
+
+func ntpToTime(v uint32) time.Time {
+	s := int64(v)
+	if s < ntpEraHalf {
+		s += ntpEra
+	}
+	return time.Unix(s-ntpOffset, 0)
+}
@@ -0,0 +1,236 @@
+package diameter
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		addr Address
+		data []byte
+		str  string
+	}{
+		{"IPv4", IPAddress(net.ParseIP("192.0.2.1")),
+			[]byte{0, 1, 192, 0, 2, 1}, "192.0.2.1"},
+		{"IPv6", IPAddress(net.ParseIP("2001:db8::1")),
+			[]byte{0, 2, 0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+			"2001:db8::1"},
+		{"E.164", Address{Family: AddrE164, Value: []byte("819012345678")},
+			append([]byte{0, 8}, "819012345678"...), "819012345678"},
+		{"DNS", Address{Family: AddrDNS, Value: []byte("host.example.com")},
+			append([]byte{0, 16}, "host.example.com"...), "host.example.com"},
+		{"802", Address{Family: Addr802, Value: []byte{0, 0x11, 0x22, 0x33, 0x44, 0x55}},
+			[]byte{0, 6, 0, 0x11, 0x22, 0x33, 0x44, 0x55}, "00:11:22:33:44:55"},
+		{"other", Address{Family: AddrX121, Value: []byte{0x12, 0x34}},
+			[]byte{0, 10, 0x12, 0x34}, "family=10:12 34"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := RawAVP{Code: 257}
+			if e := a.Encode(tt.addr); e != nil {
+				t.Fatalf("encode failed: %s", e)
+			}
+			if !bytes.Equal(a.data, tt.data) {
+				t.Fatalf("encoded % x, want % x", a.data, tt.data)
+			}
+			var d Address
+			if e := a.Decode(&d); e != nil {
+				t.Fatalf("decode failed: %s", e)
+			}
+			if d.Family != tt.addr.Family || !bytes.Equal(d.Value, tt.addr.Value) {
+				t.Errorf("decoded %v, want %v", d, tt.addr)
+			}
+			if s := d.String(); s != tt.str {
+				t.Errorf("string %q, want %q", s, tt.str)
+			}
+		})
+	}
+}
+
+func TestAddressInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"no family", []byte{0}},
+		{"short IPv4", []byte{0, 1, 192, 0, 2}},
+		{"long IPv4", []byte{0, 1, 192, 0, 2, 1, 0}},
+		{"short IPv6", []byte{0, 2, 0x20, 0x01}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := RawAVP{Code: 257, data: tt.data}
+			var d Address
+			if e := a.Decode(&d); e == nil {
+				t.Errorf("decoded %v from invalid data", d)
+			}
+			if len(tt.data) < 2 {
+				return
+			}
+			if e := a.Encode(Address{Family: AddrIPv4, Value: tt.data[2:]}); tt.data[1] == 1 && e == nil {
+				t.Errorf("encoded invalid IPv4 address")
+			}
+		})
+	}
+
+	a := RawAVP{Code: 257}
+	a.Encode(Address{Family: AddrE164, Value: []byte("8190")})
+	var ip net.IP
+	if e := a.Decode(&ip); e == nil {
+		t.Errorf("decoded E.164 address as net.IP %s", ip)
+	}
+}
+
+func TestTime(t *testing.T) {
+	tests := []struct {
+		name string
+		time time.Time
+		data []byte
+	}{
+		{"1970", time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+			[]byte{0x83, 0xaa, 0x7e, 0x80}},
+		{"2000", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+			[]byte{0xbc, 0x17, 0xc2, 0x00}},
+		{"end of era 0", time.Date(2036, 2, 7, 6, 28, 15, 0, time.UTC),
+			[]byte{0xff, 0xff, 0xff, 0xff}},
+		{"start of era 1", time.Date(2036, 2, 7, 6, 28, 16, 0, time.UTC),
+			[]byte{0x00, 0x00, 0x00, 0x00}},
+		{"2050", time.Date(2050, 1, 1, 0, 0, 0, 0, time.UTC),
+			[]byte{0x1a, 0x24, 0xf4, 0x80}},
+		{"end of range", time.Date(2104, 2, 26, 9, 42, 23, 0, time.UTC),
+			[]byte{0x7f, 0xff, 0xff, 0xff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := RawAVP{Code: 55}
+			if e := a.Encode(tt.time); e != nil {
+				t.Fatalf("encode failed: %s", e)
+			}
+			if !bytes.Equal(a.data, tt.data) {
+				t.Fatalf("encoded % x, want % x", a.data, tt.data)
+			}
+			var d time.Time
+			if e := a.Decode(&d); e != nil {
+				t.Fatalf("decode failed: %s", e)
+			}
+			if !d.Equal(tt.time) {
+				t.Errorf("decoded %s, want %s", d.UTC(), tt.time)
+			}
+		})
+	}
+
+	for _, tm := range []time.Time{
+		time.Date(1968, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2105, 1, 1, 0, 0, 0, 0, time.UTC)} {
+		a := RawAVP{Code: 55}
+		if e := a.Encode(tm); e == nil {
+			t.Errorf("encoded out of range time %s", tm)
+		}
+	}
+
+	// 8 octets value of old implementation
+	a := RawAVP{Code: 55, data: []byte{0, 0, 0, 0, 0xbc, 0x17, 0xc2, 0x00}}
+	var d time.Time
+	if e := a.Decode(&d); e != nil {
+		t.Errorf("decode of 8 octets failed: %s", e)
+	} else if want := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC); !d.Equal(want) {
+		t.Errorf("decoded %s, want %s", d.UTC(), want)
+	}
+	a.data = []byte{0, 0, 0}
+	if e := a.Decode(&d); e == nil {
+		t.Errorf("decoded 3 octets time")
+	}
+}
+
+func TestUTF8String(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		valid bool
+	}{
+		{"ASCII", []byte("user@example.com"), true},
+		{"multibyte", []byte("ユーザー"), true},
+		{"empty", []byte{}, true},
+		{"invalid octet", []byte{0x61, 0xff, 0x62}, false},
+		{"truncated", []byte{0xe3, 0x83}, false},
+		{"surrogate", []byte{0xed, 0xa0, 0x80}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := RawAVP{Code: 1}
+			if e := a.Encode(string(tt.data)); (e == nil) != tt.valid {
+				t.Errorf("encode error %v, valid %t", e, tt.valid)
+			}
+			a.data = tt.data
+			var s string
+			if e := a.Decode(&s); (e == nil) != tt.valid {
+				t.Errorf("decode error %v, valid %t", e, tt.valid)
+			} else if tt.valid && s != string(tt.data) {
+				t.Errorf("decoded %q, want %q", s, tt.data)
+			}
+		})
+	}
+}
+
+func TestEnumerated(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		value Enumerated
+		valid bool
+	}{
+		{"zero", []byte{0, 0, 0, 0}, 0, true},
+		{"positive", []byte{0, 0, 0x07, 0xd1}, 2001, true},
+		{"negative", []byte{0xff, 0xff, 0xff, 0xff}, -1, true},
+		{"short", []byte{0, 0, 1}, 0, false},
+		{"long", []byte{0, 0, 0, 0, 1}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := RawAVP{Code: 274, data: tt.data}
+			d := Enumerated(-100)
+			if e := a.Decode(&d); (e == nil) != tt.valid {
+				t.Fatalf("decode error %v, valid %t", e, tt.valid)
+			}
+			if tt.valid && d != tt.value {
+				t.Errorf("decoded %d, want %d", d, tt.value)
+			}
+		})
+	}
+}
+
+func TestAuthSessionState(t *testing.T) {
+	tests := []struct {
+		name  string
+		avp   RawAVP
+		value bool
+		err   error
+	}{
+		{"STATE_MAINTAINED", SetAuthSessionState(true), true, nil},
+		{"NO_STATE_MAINTAINED", SetAuthSessionState(false), false, nil},
+		{"unknown value", RawAVP{Code: 277, FlgM: true, data: []byte{0, 0, 0, 2}},
+			false, InvalidAVP(DiameterInvalidAvpValue)},
+		{"no M-bit", RawAVP{Code: 277, data: []byte{0, 0, 0, 0}},
+			false, InvalidAVP(DiameterInvalidAvpBits)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, e := GetAuthSessionState(tt.avp)
+			if e != tt.err {
+				t.Fatalf("error %v, want %v", e, tt.err)
+			}
+			if v != tt.value {
+				t.Errorf("value %t, want %t", v, tt.value)
+			}
+		})
+	}
+
+	a := RawAVP{Code: 277, FlgM: true, data: []byte{0, 0, 1}}
+	if _, e := GetAuthSessionState(a); e == nil {
+		t.Errorf("decoded invalid length value")
+	}
+}
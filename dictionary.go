@@ -0,0 +1,602 @@
+package diameter
+
+import (
+	"bytes"
+	_ "embed" // for built-in dictionary
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	//go:embed dictionary/base.xml
+	baseDictionary string
+	//go:embed dictionary/3gpp.xml
+	tgppDictionary string
+)
+
+// FlagRule is rule of AVP flag bit
+type FlagRule int
+
+const (
+	// FlagMay is flag that may be set
+	FlagMay FlagRule = iota
+	// FlagMust is flag that must be set
+	FlagMust
+	// FlagMustNot is flag that must not be set
+	FlagMustNot
+	// FlagShouldNot is flag that should not be set
+	FlagShouldNot
+)
+
+func (r FlagRule) String() string {
+	switch r {
+	case FlagMay:
+		return "may"
+	case FlagMust:
+		return "must"
+	case FlagMustNot:
+		return "mustnot"
+	case FlagShouldNot:
+		return "shouldnot"
+	}
+	return "<nil>"
+}
+
+func parseFlagRule(s string, d FlagRule) (FlagRule, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return d, nil
+	case "may", "yes":
+		return FlagMay, nil
+	case "must":
+		return FlagMust, nil
+	case "mustnot", "no":
+		return FlagMustNot, nil
+	case "shouldnot":
+		return FlagShouldNot, nil
+	}
+	return d, fmt.Errorf("invalid flag rule %s", s)
+}
+
+// DictVendor is vendor definition in dictionary
+type DictVendor struct {
+	ID   uint32
+	Name string
+}
+
+// DictRule is occurrence rule of AVP in command or grouped AVP.
+// Name "AVP" means any AVP.
+type DictRule struct {
+	Name string
+	// Fixed is true when the AVP must be at fixed position
+	Fixed bool
+	// Min is minimum occurrence of the AVP
+	Min int
+	// Max is maximum occurrence of the AVP, or -1 for unbounded
+	Max int
+}
+
+// DictAVP is AVP definition in dictionary
+type DictAVP struct {
+	Code  uint32
+	VenID uint32
+	Name  string
+	// Type is basic or derived data type name of RFC 6733,
+	// for example "Unsigned32" or "DiameterIdentity".
+	Type      string
+	VendorBit FlagRule
+	Mandatory FlagRule
+	Protected FlagRule
+	// Enum is name of enumerated value
+	Enum map[int64]string
+	// Group is rules of AVPs in Grouped AVP
+	Group []DictRule
+}
+
+// DictCommand is command definition in dictionary
+type DictCommand struct {
+	Code    uint32
+	AppID   uint32
+	Name    string
+	Request []DictRule
+	Answer  []DictRule
+}
+
+// DictApp is application definition in dictionary
+type DictApp struct {
+	ID   uint32
+	Name string
+}
+
+type dictAVPKey struct {
+	code  uint32
+	venID uint32
+}
+
+type dictCmdKey struct {
+	appID uint32
+	code  uint32
+}
+
+// dictSet is set of registered definitions
+type dictSet struct {
+	typ     map[string]string
+	vendor  map[uint32]DictVendor
+	app     map[uint32]DictApp
+	avp     map[dictAVPKey]DictAVP
+	avpName map[string]DictAVP
+	cmd     map[dictCmdKey]DictCommand
+	cmdName map[string]DictCommand
+}
+
+var (
+	dict = dictSet{
+		typ:     make(map[string]string),
+		vendor:  make(map[uint32]DictVendor),
+		app:     make(map[uint32]DictApp),
+		avp:     make(map[dictAVPKey]DictAVP),
+		avpName: make(map[string]DictAVP),
+		cmd:     make(map[dictCmdKey]DictCommand),
+		cmdName: make(map[string]DictCommand)}
+	dictMutex sync.RWMutex
+)
+
+// clone returns copy of the set that can be updated
+// without changing this set
+func (d dictSet) clone() dictSet {
+	c := dictSet{
+		typ:     make(map[string]string, len(d.typ)),
+		vendor:  make(map[uint32]DictVendor, len(d.vendor)),
+		app:     make(map[uint32]DictApp, len(d.app)),
+		avp:     make(map[dictAVPKey]DictAVP, len(d.avp)),
+		avpName: make(map[string]DictAVP, len(d.avpName)),
+		cmd:     make(map[dictCmdKey]DictCommand, len(d.cmd)),
+		cmdName: make(map[string]DictCommand, len(d.cmdName))}
+	for k, v := range d.typ {
+		c.typ[k] = v
+	}
+	for k, v := range d.vendor {
+		c.vendor[k] = v
+	}
+	for k, v := range d.app {
+		c.app[k] = v
+	}
+	for k, v := range d.avp {
+		c.avp[k] = v
+	}
+	for k, v := range d.avpName {
+		c.avpName[k] = v
+	}
+	for k, v := range d.cmd {
+		c.cmd[k] = v
+	}
+	for k, v := range d.cmdName {
+		c.cmdName[k] = v
+	}
+	return c
+}
+
+func init() {
+	for _, d := range []string{baseDictionary, tgppDictionary} {
+		if e := LoadDictionary(strings.NewReader(d)); e != nil {
+			panic("invalid built-in dictionary: " + e.Error())
+		}
+	}
+}
+
+// LookupVendor returns vendor definition of the Vendor-ID
+func LookupVendor(id uint32) (DictVendor, bool) {
+	dictMutex.RLock()
+	defer dictMutex.RUnlock()
+	v, ok := dict.vendor[id]
+	return v, ok
+}
+
+// LookupApplication returns application definition of the Application-ID
+func LookupApplication(id uint32) (DictApp, bool) {
+	dictMutex.RLock()
+	defer dictMutex.RUnlock()
+	v, ok := dict.app[id]
+	return v, ok
+}
+
+// LookupAVP returns AVP definition of the AVP code and Vendor-ID
+func LookupAVP(code, venID uint32) (DictAVP, bool) {
+	dictMutex.RLock()
+	defer dictMutex.RUnlock()
+	v, ok := dict.avp[dictAVPKey{code: code, venID: venID}]
+	return v, ok
+}
+
+// LookupAVPByName returns AVP definition of the name
+func LookupAVPByName(name string) (DictAVP, bool) {
+	dictMutex.RLock()
+	defer dictMutex.RUnlock()
+	v, ok := dict.avpName[name]
+	return v, ok
+}
+
+// LookupCommand returns command definition of the Application-ID and
+// Command-Code. Command of base protocol (Application-ID 0) is returned
+// when the application doesn't define the command.
+func LookupCommand(appID, code uint32) (DictCommand, bool) {
+	dictMutex.RLock()
+	defer dictMutex.RUnlock()
+	v, ok := dict.cmd[dictCmdKey{appID: appID, code: code}]
+	if !ok {
+		v, ok = dict.cmd[dictCmdKey{appID: 0, code: code}]
+	}
+	return v, ok
+}
+
+// LookupCommandByName returns command definition of the name
+func LookupCommandByName(name string) (DictCommand, bool) {
+	dictMutex.RLock()
+	defer dictMutex.RUnlock()
+	v, ok := dict.cmdName[name]
+	return v, ok
+}
+
+// XML dictionary format
+type xmlDictionary struct {
+	Vendor []xmlVendor `xml:"vendor"`
+	Base   xmlApp      `xml:"base"`
+	App    []xmlApp    `xml:"application"`
+}
+
+type xmlVendor struct {
+	ID   string `xml:"vendor-id,attr"`
+	Code string `xml:"code,attr"`
+	Name string `xml:"name,attr"`
+}
+
+type xmlApp struct {
+	ID      string       `xml:"id,attr"`
+	Name    string       `xml:"name,attr"`
+	Vendor  []xmlVendor  `xml:"vendor"`
+	Typedef []xmlTypedef `xml:"typedefn"`
+	Command []xmlCommand `xml:"command"`
+	AVP     []xmlAVP     `xml:"avp"`
+}
+
+type xmlTypedef struct {
+	Name   string `xml:"type-name,attr"`
+	Parent string `xml:"type-parent,attr"`
+}
+
+type xmlCommand struct {
+	Name    string   `xml:"name,attr"`
+	Code    string   `xml:"code,attr"`
+	Request xmlRules `xml:"requestrules"`
+	Answer  xmlRules `xml:"answerrules"`
+}
+
+type xmlRules struct {
+	Fixed    []xmlRule `xml:"fixed>avprule"`
+	Required []xmlRule `xml:"required>avprule"`
+	Optional []xmlRule `xml:"optional>avprule"`
+}
+
+type xmlRule struct {
+	Name string `xml:"name,attr"`
+	Min  string `xml:"minimum,attr"`
+	Max  string `xml:"maximum,attr"`
+}
+
+type xmlAVP struct {
+	Name      string `xml:"name,attr"`
+	Code      string `xml:"code,attr"`
+	VendorID  string `xml:"vendor-id,attr"`
+	Mandatory string `xml:"mandatory,attr"`
+	Protected string `xml:"protected,attr"`
+	VendorBit string `xml:"vendor-bit,attr"`
+	Type      *struct {
+		Name string `xml:"type-name,attr"`
+	} `xml:"type"`
+	Grouped *struct {
+		GAVP []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"gavp"`
+		Rule []xmlRule `xml:"avprule"`
+		xmlRules
+	} `xml:"grouped"`
+	Enum []struct {
+		Name string `xml:"name,attr"`
+		Code string `xml:"code,attr"`
+	} `xml:"enum"`
+}
+
+// basic and derived data types of RFC 6733
+var dictTypes = map[string]bool{
+	"OctetString": true, "Integer32": true, "Integer64": true,
+	"Unsigned32": true, "Unsigned64": true, "Float32": true, "Float64": true,
+	"Grouped": true, "Address": true, "Time": true, "UTF8String": true,
+	"DiameterIdentity": true, "DiameterURI": true, "Enumerated": true,
+	"IPFilterRule": true, "QoSFilterRule": true}
+
+// LoadDictionaryFile read dictionary XML file and register definitions.
+// Files of external entity in the DOCTYPE are read from
+// the directory of the file or its sub directory,
+// and other file is not allowed.
+func LoadDictionaryFile(path string) error {
+	b, e := os.ReadFile(path)
+	if e != nil {
+		return e
+	}
+	if b, e = expandEntity(b, filepath.Dir(path)); e != nil {
+		return e
+	}
+	return loadDictionary(b)
+}
+
+// LoadDictionary read dictionary XML in Wireshark (dictionary.xml) format
+// and register vendors, applications, commands and AVPs.
+// Definition that has same code as existing one replaces it.
+// External entity in the DOCTYPE is not read from the file
+// and reference of it is error, so use LoadDictionaryFile for
+// dictionary that includes other files.
+// Registered definitions are not changed when it returns error.
+func LoadDictionary(r io.Reader) error {
+	b, e := io.ReadAll(r)
+	if e != nil {
+		return e
+	}
+	for _, m := range dictEntity.FindAllSubmatch(b, -1) {
+		if bytes.Contains(b, []byte("&"+string(m[1])+";")) {
+			return fmt.Errorf("external entity %s is not allowed", m[1])
+		}
+	}
+	return loadDictionary(b)
+}
+
+// dictEntity is external entity declaration in DOCTYPE,
+// such as <!ENTITY nasreq SYSTEM "nasreq.xml">
+var dictEntity = regexp.MustCompile(
+	`<!ENTITY\s+([\w.-]+)\s+SYSTEM\s+["']([^"']+)["']\s*>`)
+
+// expandEntity replace reference of external entity with
+// content of the file, as Wireshark dictionary.xml includes
+// files of each vendor and application.
+// The file must be in dir or its sub directory.
+func expandEntity(b []byte, dir string) ([]byte, error) {
+	for _, m := range dictEntity.FindAllSubmatch(b, -1) {
+		ref := []byte("&" + string(m[1]) + ";")
+		if !bytes.Contains(b, ref) {
+			continue
+		}
+		path := filepath.Clean(filepath.FromSlash(string(m[2])))
+		if filepath.IsAbs(path) || filepath.VolumeName(path) != "" ||
+			path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf(
+				"file %s of external entity %s is out of dictionary directory",
+				m[2], m[1])
+		}
+		inc, e := os.ReadFile(filepath.Join(dir, path))
+		if e != nil {
+			return nil, e
+		}
+		// XML declaration is not allowed in the middle of document
+		if bytes.HasPrefix(bytes.TrimSpace(inc), []byte("<?xml")) {
+			inc = bytes.TrimSpace(inc)
+			inc = inc[bytes.Index(inc, []byte("?>"))+2:]
+		}
+		b = bytes.Replace(b, ref, inc, -1)
+	}
+	return b, nil
+}
+
+func loadDictionary(b []byte) error {
+	d := xmlDictionary{}
+	if e := xml.Unmarshal(b, &d); e != nil {
+		return e
+	}
+
+	dictMutex.Lock()
+	defer dictMutex.Unlock()
+
+	// definitions are registered in copy of the set,
+	// and it replaces the set only when all definitions are valid
+	s := dict.clone()
+
+	// vendor-id attribute of AVP refers vendor by the name or code
+	vendors := make(map[string]uint32)
+	for _, v := range s.vendor {
+		vendors[v.Name] = v.ID
+	}
+	vs := append([]xmlVendor{}, d.Vendor...)
+	vs = append(vs, d.Base.Vendor...)
+	for _, a := range d.App {
+		vs = append(vs, a.Vendor...)
+	}
+	for _, v := range vs {
+		id, e := parseDictUint(v.Code)
+		if e != nil {
+			return fmt.Errorf("invalid code of vendor %s: %s", v.ID, e)
+		}
+		if len(v.Name) == 0 {
+			v.Name = v.ID
+		}
+		vendors[v.ID] = id
+		vendors[v.Name] = id
+		s.vendor[id] = DictVendor{ID: id, Name: v.Name}
+	}
+
+	for _, a := range append([]xmlApp{d.Base}, d.App...) {
+		for _, t := range a.Typedef {
+			s.typ[t.Name] = t.Parent
+		}
+	}
+
+	for i, a := range append([]xmlApp{d.Base}, d.App...) {
+		var id uint32
+		if i != 0 {
+			var e error
+			if id, e = parseDictUint(a.ID); e != nil {
+				return fmt.Errorf("invalid id of application %s: %s", a.Name, e)
+			}
+			s.app[id] = DictApp{ID: id, Name: a.Name}
+		}
+		for _, x := range a.AVP {
+			v, e := x.toDict(vendors, s.typ)
+			if e != nil {
+				return e
+			}
+			s.avp[dictAVPKey{code: v.Code, venID: v.VenID}] = v
+			s.avpName[v.Name] = v
+		}
+		for _, x := range a.Command {
+			v, e := x.toDict(id)
+			if e != nil {
+				return e
+			}
+			s.cmd[dictCmdKey{appID: v.AppID, code: v.Code}] = v
+			s.cmdName[v.Name] = v
+		}
+	}
+	dict = s
+	return nil
+}
+
+func parseDictUint(s string) (uint32, error) {
+	v, e := strconv.ParseUint(strings.TrimSpace(s), 0, 32)
+	return uint32(v), e
+}
+
+func (x xmlAVP) toDict(vendors map[string]uint32, types map[string]string) (v DictAVP, e error) {
+	v.Name = x.Name
+	if v.Code, e = parseDictUint(x.Code); e != nil {
+		e = fmt.Errorf("invalid code of AVP %s: %s", x.Name, e)
+		return
+	}
+	switch x.VendorID {
+	case "", "None", "0":
+	default:
+		var ok bool
+		if v.VenID, ok = vendors[x.VendorID]; !ok {
+			if v.VenID, e = parseDictUint(x.VendorID); e != nil {
+				e = fmt.Errorf("unknown vendor %s of AVP %s", x.VendorID, x.Name)
+				return
+			}
+		}
+	}
+
+	vb := FlagMustNot
+	if v.VenID != 0 {
+		vb = FlagMust
+	}
+	if v.VendorBit, e = parseFlagRule(x.VendorBit, vb); e != nil {
+		return
+	}
+	if v.Mandatory, e = parseFlagRule(x.Mandatory, FlagMay); e != nil {
+		return
+	}
+	if v.Protected, e = parseFlagRule(x.Protected, FlagMay); e != nil {
+		return
+	}
+
+	switch {
+	case x.Grouped != nil:
+		v.Type = "Grouped"
+		for _, g := range x.Grouped.GAVP {
+			v.Group = append(v.Group, DictRule{Name: g.Name, Min: 0, Max: -1})
+		}
+		rs := x.Grouped.xmlRules
+		rs.Optional = append(rs.Optional, x.Grouped.Rule...)
+		var dr []DictRule
+		if dr, e = rs.toDict(); e != nil {
+			e = fmt.Errorf("invalid rule of AVP %s: %s", x.Name, e)
+			return
+		}
+		v.Group = append(v.Group, dr...)
+	case x.Type != nil:
+		t := x.Type.Name
+		for i := 0; !dictTypes[t] && i < 16; i++ {
+			p, ok := types[t]
+			if !ok {
+				break
+			}
+			t = p
+		}
+		if !dictTypes[t] {
+			e = fmt.Errorf("unknown type %s of AVP %s", x.Type.Name, x.Name)
+			return
+		}
+		v.Type = t
+	default:
+		e = fmt.Errorf("no type of AVP %s", x.Name)
+		return
+	}
+
+	if len(x.Enum) != 0 {
+		v.Enum = make(map[int64]string, len(x.Enum))
+		for _, n := range x.Enum {
+			c, err := strconv.ParseInt(strings.TrimSpace(n.Code), 0, 64)
+			if err != nil {
+				e = fmt.Errorf("invalid enum %s of AVP %s: %s", n.Name, x.Name, err)
+				return
+			}
+			v.Enum[c] = n.Name
+		}
+	}
+	return
+}
+
+func (x xmlCommand) toDict(appID uint32) (v DictCommand, e error) {
+	v.Name = x.Name
+	v.AppID = appID
+	if v.Code, e = parseDictUint(x.Code); e != nil {
+		e = fmt.Errorf("invalid code of command %s: %s", x.Name, e)
+		return
+	}
+	if v.Request, e = x.Request.toDict(); e != nil {
+		e = fmt.Errorf("invalid request rule of command %s: %s", x.Name, e)
+		return
+	}
+	if v.Answer, e = x.Answer.toDict(); e != nil {
+		e = fmt.Errorf("invalid answer rule of command %s: %s", x.Name, e)
+	}
+	return
+}
+
+func (x xmlRules) toDict() (r []DictRule, e error) {
+	add := func(rs []xmlRule, fixed bool, min int) error {
+		for _, xr := range rs {
+			v := DictRule{Name: xr.Name, Fixed: fixed, Min: min, Max: 1}
+			if len(xr.Min) != 0 {
+				i, e := strconv.Atoi(xr.Min)
+				if e != nil {
+					return e
+				}
+				v.Min = i
+			}
+			switch xr.Max {
+			case "":
+			case "unbounded", "*":
+				v.Max = -1
+			default:
+				i, e := strconv.Atoi(xr.Max)
+				if e != nil {
+					return e
+				}
+				v.Max = i
+			}
+			r = append(r, v)
+		}
+		return nil
+	}
+	if e = add(x.Fixed, true, 1); e != nil {
+		return
+	}
+	if e = add(x.Required, false, 1); e != nil {
+		return
+	}
+	e = add(x.Optional, false, 0)
+	return
+}
@@ -0,0 +1,68 @@
+package diameter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDictionaryEntity(t *testing.T) {
+	d := t.TempDir()
+	sub := filepath.Join(d, "sub")
+	os.Mkdir(sub, 0755)
+	os.WriteFile(filepath.Join(d, "Example.xml"), []byte(`<?xml version="1.0"?>
+<vendor vendor-id="Ent" code="99996" name="Entity Example"/>
+<application id="99996" name="Entity Example App">
+	<avp name="Entity-Example-Value" code="1" vendor-id="Ent" mandatory="must"><type type-name="Unsigned32"/></avp>
+</application>`), 0644)
+	os.WriteFile(filepath.Join(sub, "Example.xml"), []byte(`<application id="99995" name="Entity Sub App">
+	<avp name="Entity-Sub-Value" code="2" vendor-id="Ent" mandatory="must"><type type-name="Unsigned32"/></avp>
+</application>`), 0644)
+
+	dict := func(includes ...string) string {
+		b := new(strings.Builder)
+		b.WriteString("<?xml version=\"1.0\"?>\n<!DOCTYPE dictionary SYSTEM \"dictionary.dtd\" [\n")
+		for i, f := range includes {
+			b.WriteString("<!ENTITY e" + string(rune('0'+i)) + " SYSTEM \"" + f + "\">\n")
+		}
+		b.WriteString("]>\n<dictionary>\n")
+		for i := range includes {
+			b.WriteString("&e" + string(rune('0'+i)) + ";\n")
+		}
+		b.WriteString("</dictionary>")
+		return b.String()
+	}
+
+	tests := []struct {
+		name     string
+		dir      string
+		includes []string
+		valid    bool
+	}{
+		{"same directory", d, []string{"Example.xml"}, true},
+		{"sub directory", d, []string{"Example.xml", "sub/Example.xml"}, true},
+		{"dot relative", d, []string{"./Example.xml", "sub/../sub/Example.xml"}, true},
+		{"parent directory", sub, []string{"../Example.xml"}, false},
+		{"escape from sub directory", sub, []string{"Example.xml", "../../Example.xml"}, false},
+		{"absolute path", sub, []string{filepath.Join(d, "Example.xml")}, false},
+		{"missing file", d, []string{"Missing.xml"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(tt.dir, "dictionary.xml")
+			os.WriteFile(p, []byte(dict(tt.includes...)), 0644)
+			if e := LoadDictionaryFile(p); (e == nil) != tt.valid {
+				t.Fatalf("load error %v, valid %t", e, tt.valid)
+			}
+		})
+	}
+	if _, ok := LookupAVPByName("Entity-Sub-Value"); !ok {
+		t.Errorf("AVP in sub directory is not loaded")
+	}
+
+	e := LoadDictionary(strings.NewReader(dict("Example.xml")))
+	if e == nil {
+		t.Errorf("external entity is read by LoadDictionary")
+	}
+}
@@ -0,0 +1,285 @@
+package diameter
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// OLRDefaultAlgo is OC-Feature-Vector value for loss abatement algorithm
+	OLRDefaultAlgo uint64 = 0x0000000000000001
+)
+
+var (
+	// OCFeatureVector is OC-Feature-Vector value of this node.
+	// Diameter overload indication conveyance (RFC7683) is disabled
+	// when value is 0.
+	OCFeatureVector uint64
+	// OCReductionPercentage is OC-Reduction-Percentage value
+	// that is reported when this node is overloaded.
+	OCReductionPercentage uint32 = 50
+	// OCValidityDuration is OC-Validity-Duration value
+	// that is reported when this node is overloaded.
+	OCValidityDuration = time.Second * 30
+
+	ocSeq   = uint64(time.Now().Unix())
+	ocHost  = make(map[Identity]overload)
+	ocRealm = make(map[Identity]overload)
+	ocMutex sync.Mutex
+)
+
+// OCReportType is OC-Report-Type value
+type OCReportType Enumerated
+
+const (
+	// HostReport is Enumerated value 0
+	HostReport OCReportType = 0
+	// RealmReport is Enumerated value 1
+	RealmReport OCReportType = 1
+)
+
+func (t OCReportType) String() string {
+	switch t {
+	case HostReport:
+		return "HOST_REPORT"
+	case RealmReport:
+		return "REALM_REPORT"
+	}
+	return "<nil>"
+}
+
+// OLR is overload report in OC-OLR AVP
+type OLR struct {
+	SequenceNumber      uint64
+	ReportType          OCReportType
+	ReductionPercentage uint32
+	ValidityDuration    time.Duration
+}
+
+// SetOCSupportedFeatures make OC-Supported-Features AVP
+func SetOCSupportedFeatures(v uint64) (a RawAVP) {
+	a = RawAVP{Code: 621, VenID: 0, FlgV: false, FlgM: false, FlgP: false}
+	t := RawAVP{Code: 622, VenID: 0, FlgV: false, FlgM: false, FlgP: false}
+	t.Encode(v)
+	a.Encode([]RawAVP{t})
+	return
+}
+
+// GetOCSupportedFeatures read OC-Supported-Features AVP
+func GetOCSupportedFeatures(a RawAVP) (v uint64, e error) {
+	o := []RawAVP{}
+	if a.FlgV || a.FlgP {
+		e = InvalidAVP(DiameterInvalidAvpBits)
+	} else {
+		e = a.Decode(&o)
+	}
+	for _, a := range o {
+		if a.VenID == 0 && a.Code == 622 {
+			e = a.Decode(&v)
+		}
+	}
+	return
+}
+
+// SetOCOLR make OC-OLR AVP
+func SetOCOLR(v OLR) (a RawAVP) {
+	a = RawAVP{Code: 623, VenID: 0, FlgV: false, FlgM: false, FlgP: false}
+	t := []RawAVP{
+		RawAVP{Code: 624, VenID: 0, FlgV: false, FlgM: false, FlgP: false},
+		RawAVP{Code: 626, VenID: 0, FlgV: false, FlgM: false, FlgP: false},
+		RawAVP{Code: 627, VenID: 0, FlgV: false, FlgM: false, FlgP: false},
+		RawAVP{Code: 625, VenID: 0, FlgV: false, FlgM: false, FlgP: false}}
+	t[0].Encode(v.SequenceNumber)
+	t[1].Encode(Enumerated(v.ReportType))
+	t[2].Encode(v.ReductionPercentage)
+	t[3].Encode(uint32(v.ValidityDuration / time.Second))
+	a.Encode(t)
+	return
+}
+
+// GetOCOLR read OC-OLR AVP
+func GetOCOLR(a RawAVP) (v OLR, e error) {
+	o := []RawAVP{}
+	if a.FlgV || a.FlgP {
+		e = InvalidAVP(DiameterInvalidAvpBits)
+	} else {
+		e = a.Decode(&o)
+	}
+	v.ValidityDuration = time.Second * 30
+	seq, typ := false, false
+	for _, a := range o {
+		if a.VenID != 0 || e != nil {
+			continue
+		}
+		switch a.Code {
+		case 624:
+			e = a.Decode(&v.SequenceNumber)
+			seq = true
+		case 626:
+			var t int32
+			e = a.Decode(&t)
+			v.ReportType = OCReportType(t)
+			typ = true
+		case 627:
+			e = a.Decode(&v.ReductionPercentage)
+		case 625:
+			var t uint32
+			e = a.Decode(&t)
+			v.ValidityDuration = time.Second * time.Duration(t)
+		}
+	}
+	if e != nil {
+	} else if !seq || !typ {
+		e = InvalidAVP(DiameterMissingAvp)
+	} else if v.ReportType != HostReport && v.ReportType != RealmReport {
+		e = InvalidAVP(DiameterInvalidAvpValue)
+	} else if v.ReductionPercentage > 100 {
+		e = InvalidAVP(DiameterInvalidAvpValue)
+	} else if v.ValidityDuration > time.Second*86400 {
+		e = InvalidAVP(DiameterInvalidAvpValue)
+	}
+	return
+}
+
+type overload struct {
+	seq       uint64
+	reduction uint32
+	expire    time.Time
+}
+
+// abate returns true if the request should be throttled
+// by overload report from host or realm.
+func abate(c *Conn, m RawMsg) bool {
+	var host, realm Identity
+	for _, a := range m.AVP {
+		if a.VenID != 0 {
+			continue
+		}
+		switch a.Code {
+		case 293:
+			host, _ = GetDestinationHost(a)
+		case 283:
+			realm, _ = GetDestinationRealm(a)
+		}
+	}
+
+	var r uint32
+	now := time.Now()
+	ocMutex.Lock()
+	if len(host) == 0 {
+		if o, ok := ocRealm[realm]; ok && now.Before(o.expire) {
+			r = o.reduction
+		}
+		host = c.Peer.Host
+	}
+	if o, ok := ocHost[host]; ok && now.Before(o.expire) && o.reduction > r {
+		r = o.reduction
+	}
+	ocMutex.Unlock()
+
+	return r != 0 && uint32(rand.Intn(100)) < r
+}
+
+// handleOLR update overload report from the answer
+func handleOLR(c *Conn, m RawMsg) {
+	var olr OLR
+	var host, realm Identity
+	found := false
+	for _, a := range m.AVP {
+		if a.VenID != 0 {
+			continue
+		}
+		switch a.Code {
+		case 623:
+			var e error
+			olr, e = GetOCOLR(a)
+			found = e == nil
+		case 264:
+			host, _ = GetOriginHost(a)
+		case 296:
+			realm, _ = GetOriginRealm(a)
+		}
+	}
+	if !found {
+		return
+	}
+
+	key, table := host, ocHost
+	if olr.ReportType == RealmReport {
+		key, table = realm, ocRealm
+	}
+	if len(key) == 0 {
+		return
+	}
+
+	ocMutex.Lock()
+	o, ok := table[key]
+	if ok && o.seq >= olr.SequenceNumber {
+		ocMutex.Unlock()
+		return
+	}
+	if olr.ValidityDuration == 0 {
+		delete(table, key)
+	} else {
+		table[key] = overload{
+			seq:       olr.SequenceNumber,
+			reduction: olr.ReductionPercentage,
+			expire:    time.Now().Add(olr.ValidityDuration)}
+	}
+	ocMutex.Unlock()
+
+	publish(OverloadEvent{OLR: olr, Node: key, Conn: c})
+}
+
+// setOCRequest add OC-Supported-Features AVP to the request
+// when the application doesn't add it
+func setOCRequest(req *RawMsg) {
+	for _, a := range req.AVP {
+		if a.VenID == 0 && a.Code == 621 {
+			return
+		}
+	}
+	req.AVP = append(req.AVP, SetOCSupportedFeatures(OCFeatureVector))
+}
+
+// setOCAnswer add DOIC AVPs to the answer for the request
+func setOCAnswer(c *Conn, req RawMsg, ans *RawMsg) {
+	if OCFeatureVector == 0 {
+		return
+	}
+	var v uint64
+	found := false
+	for _, a := range req.AVP {
+		if a.VenID == 0 && a.Code == 621 {
+			v, _ = GetOCSupportedFeatures(a)
+			found = true
+			break
+		}
+	}
+	if !found {
+		return
+	}
+	for _, a := range ans.AVP {
+		if a.VenID == 0 && (a.Code == 621 || a.Code == 623) {
+			// already added by application
+			return
+		}
+	}
+
+	// select one algorithm that is supported by both nodes,
+	// and only loss algorithm is supported
+	algo := v & OCFeatureVector & OLRDefaultAlgo
+	if algo == 0 {
+		return
+	}
+	ans.AVP = append(ans.AVP, SetOCSupportedFeatures(algo))
+	if atomic.LoadInt32(&c.shedding) != 0 {
+		ans.AVP = append(ans.AVP, SetOCOLR(OLR{
+			SequenceNumber:      atomic.LoadUint64(&ocSeq),
+			ReportType:          HostReport,
+			ReductionPercentage: OCReductionPercentage,
+			ValidityDuration:    OCValidityDuration}))
+	}
+}
@@ -0,0 +1,164 @@
+package diameter
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DuplicateLifetime is lifetime of recieved request record for
+// duplicate detection. Detection is disabled when value is 0.
+var DuplicateLifetime = time.Minute * 4
+
+type dupKey struct {
+	host Identity
+	id   uint32
+}
+
+// dupEntry is record of recieved request.
+// ans is zero until the request is answered, and waiting is
+// duplicated requests that wait for the answer.
+type dupEntry struct {
+	key     dupKey
+	ans     RawMsg
+	expire  time.Time
+	waiting []dupWaiter
+	elem    *list.Element
+}
+
+type dupWaiter struct {
+	conn *Conn
+	req  RawMsg
+}
+
+var (
+	dupCache = make(map[dupKey]*dupEntry)
+	dupList  = list.New() // entries in order of expire time
+	dupTimer *time.Timer  // single sweeper of expired entries
+	dupMutex sync.Mutex
+)
+
+func getDupKey(m RawMsg) (k dupKey, ok bool) {
+	for _, a := range m.AVP {
+		if a.Code == 264 && a.VenID == 0 {
+			k.host, _ = GetOriginHost(a)
+			break
+		}
+	}
+	k.id = m.EtEID
+	ok = len(k.host) != 0
+	return
+}
+
+// checkDuplicate register request and returns answer that is already sent
+// for the same request. Answer Code is 0 when the request is recieved
+// before but it is not answered yet, then the answer is sent to c
+// when the original request is answered.
+func checkDuplicate(c *Conn, m RawMsg) (RawMsg, bool) {
+	if DuplicateLifetime == 0 {
+		return RawMsg{}, false
+	}
+	k, ok := getDupKey(m)
+	if !ok {
+		return RawMsg{}, false
+	}
+
+	dupMutex.Lock()
+	defer dupMutex.Unlock()
+	if d, ok := dupCache[k]; ok {
+		if d.ans.Code == 0 {
+			d.waiting = append(d.waiting, dupWaiter{conn: c, req: m})
+		}
+		return d.ans, true
+	}
+
+	d := &dupEntry{key: k, expire: time.Now().Add(DuplicateLifetime)}
+	e := dupList.Back()
+	for e != nil && e.Value.(*dupEntry).expire.After(d.expire) {
+		e = e.Prev()
+	}
+	if e == nil {
+		d.elem = dupList.PushFront(d)
+	} else {
+		d.elem = dupList.InsertAfter(d, e)
+	}
+	dupCache[k] = d
+
+	if dupList.Front() == d.elem {
+		if dupTimer == nil {
+			dupTimer = time.AfterFunc(DuplicateLifetime, sweepDuplicate)
+		} else {
+			dupTimer.Reset(DuplicateLifetime)
+		}
+	}
+	return RawMsg{}, false
+}
+
+// sweepDuplicate removes expired records and schedules next sweep
+func sweepDuplicate() {
+	dupMutex.Lock()
+	defer dupMutex.Unlock()
+
+	now := time.Now()
+	for e := dupList.Front(); e != nil; e = dupList.Front() {
+		d := e.Value.(*dupEntry)
+		if d.expire.After(now) {
+			dupTimer.Reset(d.expire.Sub(now))
+			break
+		}
+		dupList.Remove(e)
+		delete(dupCache, d.key)
+	}
+}
+
+// purgeDuplicate remove all records of the host
+func purgeDuplicate(h Identity) {
+	dupMutex.Lock()
+	for k, d := range dupCache {
+		if k.host == h {
+			dupList.Remove(d.elem)
+			delete(dupCache, k)
+		}
+	}
+	dupMutex.Unlock()
+}
+
+// storeAnswer save answer for the request for replay,
+// and returns duplicated requests that wait for the answer.
+// DIAMETER_TOO_BUSY answer is transient, so the record is removed
+// and re-transmitted request is processed again.
+func storeAnswer(req, ans RawMsg) []dupWaiter {
+	k, ok := getDupKey(req)
+	if !ok {
+		return nil
+	}
+
+	dupMutex.Lock()
+	defer dupMutex.Unlock()
+	d, ok := dupCache[k]
+	if !ok {
+		return nil
+	}
+	if getResult(ans) == DiameterTooBusy {
+		dupList.Remove(d.elem)
+		delete(dupCache, k)
+	} else {
+		d.ans = ans
+	}
+	w := d.waiting
+	d.waiting = nil
+	return w
+}
+
+// replayAnswer send the answer to duplicated requests that wait for it
+func replayAnswer(w []dupWaiter, ans RawMsg, p DRMP) {
+	for _, d := range w {
+		a := ans
+		a.HbHID = d.req.HbHID
+		var e error
+		if !d.conn.send(a, p) {
+			e = ConnectionRefused{}
+		}
+		publish(DuplicateEvent{Replay: true, Conn: d.conn, Msg: d.req, Err: e})
+	}
+}
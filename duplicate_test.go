@@ -0,0 +1,38 @@
+package diameter
+
+import "testing"
+
+func TestDuplicateTooBusy(t *testing.T) {
+	req := RawMsg{Ver: 1, FlgR: true, Code: 316, EtEID: 0x1234,
+		AVP: []RawAVP{SetOriginHost("dup.example.com")}}
+	defer purgeDuplicate("dup.example.com")
+
+	tests := []struct {
+		name   string
+		result uint32
+		replay bool
+	}{
+		{"too busy", DiameterTooBusy, false},
+		{"command unsupported", DiameterCommandUnspported, true},
+		{"success", DiameterSuccess, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			purgeDuplicate("dup.example.com")
+			if _, dup := checkDuplicate(nil, req); dup {
+				t.Fatalf("first request is duplicated")
+			}
+			ans := RawMsg{Ver: 1, Code: 316, EtEID: req.EtEID,
+				AVP: []RawAVP{SetResultCode(tt.result)}}
+			storeAnswer(req, ans)
+
+			a, dup := checkDuplicate(nil, req)
+			if dup != tt.replay {
+				t.Fatalf("duplicated %t, want %t", dup, tt.replay)
+			}
+			if dup && getResult(a) != tt.result {
+				t.Errorf("replayed result %d, want %d", getResult(a), tt.result)
+			}
+		})
+	}
+}
@@ -37,6 +37,20 @@ func (e InvalidAVP) Error() string {
 	return "invalid AVP"
 }
 
+// UnsupportedAVP is error of unrecognised AVP that has M-bit
+type UnsupportedAVP struct {
+	AVP RawAVP
+}
+
+func (e UnsupportedAVP) Error() string {
+	return fmt.Sprintf("unsupported AVP: code=%d, vendor=%d", e.AVP.Code, e.AVP.VenID)
+}
+
+// FailedAVP returns value of Failed-AVP
+func (e UnsupportedAVP) FailedAVP() []RawAVP {
+	return []RawAVP{e.AVP}
+}
+
 // UnknownIDAnswer is error
 type UnknownIDAnswer struct {
 	RawMsg
@@ -84,3 +98,10 @@ type ConnectionRefused struct{}
 func (e ConnectionRefused) Error() string {
 	return "connection is refused"
 }
+
+// AnswerTimeout is error
+type AnswerTimeout struct{}
+
+func (e AnswerTimeout) Error() string {
+	return "answer is timed out"
+}
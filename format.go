@@ -0,0 +1,209 @@
+package diameter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	avpFormatter = make(map[dictAVPKey]func(RawAVP) string)
+	fmtMutex     sync.RWMutex
+)
+
+func init() {
+	vendor := func(a RawAVP) string {
+		if len(a.data) != 4 {
+			return ""
+		}
+		v := binary.BigEndian.Uint32(a.data)
+		if d, ok := LookupVendor(v); ok {
+			return fmt.Sprintf("%d (%s)", v, d.Name)
+		}
+		return fmt.Sprintf("%d", v)
+	}
+	app := func(a RawAVP) string {
+		if len(a.data) != 4 {
+			return ""
+		}
+		v := binary.BigEndian.Uint32(a.data)
+		if d, ok := LookupApplication(v); ok {
+			return fmt.Sprintf("%d (%s)", v, d.Name)
+		}
+		return fmt.Sprintf("%d", v)
+	}
+	RegisterAVPFormatter(265, 0, vendor)
+	RegisterAVPFormatter(266, 0, vendor)
+	RegisterAVPFormatter(258, 0, app)
+	RegisterAVPFormatter(259, 0, app)
+
+	// TBCD coded E.164 number of 3GPP
+	for _, c := range []uint32{701, 1489, 1645, 2403, 3300, 3332} {
+		RegisterAVPFormatter(c, 10415, formatTBCD)
+	}
+	// PLMN ID
+	RegisterAVPFormatter(1407, 10415, formatPLMN)
+}
+
+// RegisterAVPFormatter register formatter of the AVP value
+// that is used by FormatAVP and FormatMsg.
+// Formatter returns empty string when it can't format the value,
+// then the value is formatted by the data type in dictionary.
+func RegisterAVPFormatter(code, venID uint32, f func(RawAVP) string) {
+	fmtMutex.Lock()
+	defer fmtMutex.Unlock()
+	if f == nil {
+		delete(avpFormatter, dictAVPKey{code: code, venID: venID})
+	} else {
+		avpFormatter[dictAVPKey{code: code, venID: venID}] = f
+	}
+}
+
+// FormatMsg returns human-readable text of the message
+// with command name, AVP names and values in dictionary.
+func FormatMsg(m RawMsg) string {
+	w := new(bytes.Buffer)
+
+	name := fmt.Sprintf("Unknown-Command(%d)", m.Code)
+	if c, ok := LookupCommand(m.AppID, m.Code); ok {
+		name = c.Name
+	}
+	if m.FlgR {
+		name += "-Request"
+	} else {
+		name += "-Answer"
+	}
+	fmt.Fprintf(w, "%s (%d)\n", name, m.Code)
+
+	app := fmt.Sprintf("%d", m.AppID)
+	if m.AppID == 0 {
+		app += " (Diameter Common Messages)"
+	} else if a, ok := LookupApplication(m.AppID); ok {
+		app += fmt.Sprintf(" (%s)", a.Name)
+	}
+	fmt.Fprintf(w, "%sApplication-ID=%s\n", Indent, app)
+	fmt.Fprintf(w, "%sFlags        R=%t, P=%t, E=%t, T=%t\n",
+		Indent, m.FlgR, m.FlgP, m.FlgE, m.FlgT)
+	fmt.Fprintf(w, "%sHop-by-Hop ID =0x%08x\n", Indent, m.HbHID)
+	fmt.Fprintf(w, "%sEnd-to-End ID =0x%08x", Indent, m.EtEID)
+	for _, a := range m.AVP {
+		w.WriteString("\n")
+		formatAVP(w, a, 1)
+	}
+	return w.String()
+}
+
+// FormatAVP returns human-readable text of the AVP
+// with AVP name and value in dictionary.
+func FormatAVP(a RawAVP) string {
+	w := new(bytes.Buffer)
+	formatAVP(w, a, 0)
+	return w.String()
+}
+
+func formatAVP(w *bytes.Buffer, a RawAVP, depth int) {
+	indent := strings.Repeat(Indent, depth)
+	d, ok := LookupAVP(a.Code, a.VenID)
+	if !ok {
+		d = DictAVP{Name: "Unknown-AVP", Type: "OctetString"}
+	}
+
+	fmt.Fprintf(w, "%s%s (%d", indent, d.Name, a.Code)
+	if a.FlgV {
+		if v, ok := LookupVendor(a.VenID); ok {
+			fmt.Fprintf(w, ", %s", v.Name)
+		} else {
+			fmt.Fprintf(w, ", vendor=%d", a.VenID)
+		}
+	}
+	w.WriteString(") ")
+	for _, f := range []struct {
+		b bool
+		c byte
+	}{{a.FlgV, 'V'}, {a.FlgM, 'M'}, {a.FlgP, 'P'}} {
+		if f.b {
+			w.WriteByte(f.c)
+		} else {
+			w.WriteByte('-')
+		}
+	}
+
+	if d.Type == "Grouped" {
+		var avps []RawAVP
+		if e := a.Decode(&avps); e == nil {
+			for _, c := range avps {
+				w.WriteString("\n")
+				formatAVP(w, c, depth+1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(w, " = %s", formatValue(a, d))
+}
+
+// formatValue returns ValueString of the AVP with quote of string
+// and label of Enumerated value
+func formatValue(a RawAVP, d DictAVP) string {
+	fmtMutex.RLock()
+	f, ok := avpFormatter[dictAVPKey{code: a.Code, venID: a.VenID}]
+	fmtMutex.RUnlock()
+	if ok {
+		if s := f(a); len(s) != 0 {
+			return s
+		}
+	}
+
+	s, e := valueString(a, d.Type)
+	if e != nil || d.Type == "Grouped" {
+		return fmt.Sprintf("% x (invalid %s)", a.data, d.Type)
+	}
+	switch d.Type {
+	case "UTF8String", "DiameterIdentity", "DiameterURI",
+		"IPFilterRule", "QoSFilterRule":
+		s = strconv.Quote(s)
+	case "Integer32", "Integer64", "Unsigned32", "Unsigned64", "Enumerated":
+		if n, e := strconv.ParseInt(s, 10, 64); e == nil {
+			if l, ok := d.Enum[n]; ok {
+				s += fmt.Sprintf(" (%s)", l)
+			}
+		}
+	}
+	return s
+}
+
+// formatTBCD returns digits of TBCD string
+func formatTBCD(a RawAVP) string {
+	w := new(bytes.Buffer)
+	for _, c := range a.data {
+		for _, d := range []byte{c & 0x0f, c >> 4} {
+			switch {
+			case d < 10:
+				w.WriteByte('0' + d)
+			case d == 0x0f:
+			default:
+				w.WriteByte("*#abc"[d-10])
+			}
+		}
+	}
+	if w.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s (% x)", w, a.data)
+}
+
+// formatPLMN returns MCC and MNC of PLMN ID
+func formatPLMN(a RawAVP) string {
+	b := a.data
+	if len(b) != 3 {
+		return ""
+	}
+	mcc := fmt.Sprintf("%d%d%d", b[0]&0x0f, b[0]>>4, b[1]&0x0f)
+	mnc := fmt.Sprintf("%d%d", b[2]&0x0f, b[2]>>4)
+	if b[1]>>4 != 0x0f {
+		mnc += fmt.Sprintf("%d", b[1]>>4)
+	}
+	return fmt.Sprintf("MCC=%s MNC=%s (% x)", mcc, mnc, b)
+}
@@ -0,0 +1,68 @@
+package diameter
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatValue(t *testing.T) {
+	avp := func(code uint32, v interface{}) RawAVP {
+		a := RawAVP{Code: code, FlgM: true}
+		if e := a.Encode(v); e != nil {
+			t.Fatalf("encode failed: %s", e)
+		}
+		return a
+	}
+	tests := []struct {
+		name string
+		avp  RawAVP
+		text string
+	}{
+		{"DiameterIdentity", avp(264, Identity("host.example.com")), `"host.example.com"`},
+		{"UTF8String", avp(281, "a \"b\""), `"a \"b\""`},
+		{"Unsigned32", avp(278, uint32(7)), "7"},
+		{"Unsigned32 with label", avp(268, uint32(2001)), "2001 (DIAMETER_SUCCESS)"},
+		{"Enumerated", avp(273, Rebooting), "0 (REBOOTING)"},
+		{"unknown Enumerated", avp(273, Enumerated(9)), "9"},
+		{"IPv6 Address", avp(257, IPAddress(net.ParseIP("2001:db8::1"))), "2001:db8::1"},
+		{"E.164 Address", avp(257, Address{Family: AddrE164, Value: []byte("81")}), "8:3831"},
+		{"Time", avp(55, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)), "2020-01-02T03:04:05Z"},
+		{"OctetString", avp(25, []byte{1, 2}), "0102"},
+		{"invalid Unsigned32", RawAVP{Code: 268, FlgM: true, data: []byte{0, 1}},
+			"00 01 (invalid Unsigned32)"},
+		{"invalid UTF8String", RawAVP{Code: 281, FlgM: true, data: []byte{0xff}},
+			"ff (invalid UTF8String)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := FormatAVP(tt.avp)
+			if i := strings.Index(s, " = "); i < 0 || s[i+3:] != tt.text {
+				t.Fatalf("formatted %q, want value %q", s, tt.text)
+			}
+
+			// text of the value is same as ValueString
+			v, e := ValueString(tt.avp)
+			if strings.Contains(tt.text, "(invalid") {
+				if e == nil {
+					t.Errorf("ValueString of invalid value is %q", v)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatalf("ValueString failed: %s", e)
+			}
+			want := tt.text
+			if q, e := strconv.Unquote(want); e == nil {
+				want = q
+			} else if i := strings.Index(want, " ("); i >= 0 {
+				want = want[:i]
+			}
+			if v != want {
+				t.Errorf("ValueString %q, want %q", v, want)
+			}
+		})
+	}
+}
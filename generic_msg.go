@@ -13,6 +13,7 @@ type GenericReq struct {
 	VenID    uint32
 	AppID    uint32 // Application-ID
 	Stateful bool
+	DRMP
 
 	OriginHost       Identity
 	OriginRealm      Identity
@@ -25,6 +26,9 @@ type GenericReq struct {
 func (v GenericReq) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP              =%s\n", Indent, v.DRMP)
+	}
 	fmt.Fprintf(w, "%sOrigin-Host       =%s\n", Indent, v.OriginHost)
 	fmt.Fprintf(w, "%sOrigin-Realm      =%s\n", Indent, v.OriginRealm)
 	fmt.Fprintf(w, "%sDestination-Host  =%s\n", Indent, v.DestinationHost)
@@ -45,6 +49,9 @@ func (v GenericReq) ToRaw(s string) RawMsg {
 		AVP: make([]RawAVP, 0, len(v.AVP)+6)}
 
 	m.AVP = append(m.AVP, SetSessionID(s))
+	if v.DRMP != UnknownPriority {
+		m.AVP = append(m.AVP, SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, SetVendorSpecAppID(v.VenID, v.AppID))
 	m.AVP = append(m.AVP, SetAuthSessionState(v.Stateful))
 
@@ -79,6 +86,8 @@ func (GenericReq) FromRaw(m RawMsg) (Request, string, error) {
 		switch a.Code {
 		case 263:
 			s, e = GetSessionID(a)
+		case 301:
+			v.DRMP, e = GetDRMP(a)
 		case 260:
 			v.VenID, _, e = GetVendorSpecAppID(a)
 		case 277:
@@ -112,8 +121,10 @@ func (v GenericReq) Failed(c uint32) Answer {
 	return GenericAns{
 		FlgP:        v.FlgP,
 		Code:        v.Code,
+		VenID:       v.VenID,
 		AppID:       v.AppID,
 		Stateful:    v.Stateful,
+		DRMP:        v.DRMP,
 		ResultCode:  c,
 		OriginHost:  Host,
 		OriginRealm: Realm}
@@ -126,6 +137,7 @@ type GenericAns struct {
 	VenID    uint32
 	AppID    uint32 // Application-ID
 	Stateful bool
+	DRMP
 
 	ResultCode  uint32
 	OriginHost  Identity
@@ -137,6 +149,9 @@ type GenericAns struct {
 func (v GenericAns) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP            =%s\n", Indent, v.DRMP)
+	}
 	fmt.Fprintf(w, "%sResult-Code     =%d\n", Indent, v.ResultCode)
 	fmt.Fprintf(w, "%sOrigin-Host     =%s\n", Indent, v.OriginHost)
 	fmt.Fprintf(w, "%sOrigin-Realm    =%s\n", Indent, v.OriginRealm)
@@ -155,8 +170,11 @@ func (v GenericAns) ToRaw(s string) RawMsg {
 		Code: v.Code, AppID: v.AppID,
 		AVP: make([]RawAVP, 0, len(v.AVP)+6)}
 
-	m.AVP = append(m.AVP, SetResultCode(v.ResultCode))
 	m.AVP = append(m.AVP, SetSessionID(s))
+	m.AVP = append(m.AVP, SetResultCode(v.ResultCode))
+	if v.DRMP != UnknownPriority {
+		m.AVP = append(m.AVP, SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, SetVendorSpecAppID(v.VenID, v.AppID))
 	m.AVP = append(m.AVP, SetAuthSessionState(v.Stateful))
 
@@ -188,6 +206,8 @@ func (GenericAns) FromRaw(m RawMsg) (Answer, string, error) {
 			v.ResultCode, e = GetResultCode(a)
 		case 263:
 			s, e = GetSessionID(a)
+		case 301:
+			v.DRMP, e = GetDRMP(a)
 		case 260:
 			v.VenID, _, e = GetVendorSpecAppID(a)
 		case 277:
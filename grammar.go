@@ -0,0 +1,282 @@
+package diameter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValidateGrammar enables command grammar check of recieved request and answer
+// with the definition in dictionary.
+var ValidateGrammar = false
+
+// RejectUnsupportedAVP enables rejection of recieved request of
+// registered command that has unrecognised AVP with M-bit.
+// Recieved answer is not rejected, and the error is returned by SendMsg.
+var RejectUnsupportedAVP = false
+
+// Grammar is AVP rules of command ABNF
+type Grammar []DictRule
+
+// GrammarError is error of command grammar.
+// AVP is the offending AVP that is sent in Failed-AVP.
+type GrammarError struct {
+	Result uint32
+	AVP    RawAVP
+}
+
+func (e GrammarError) Error() string {
+	name := fmt.Sprintf("%d", e.AVP.Code)
+	if d, ok := LookupAVP(e.AVP.Code, e.AVP.VenID); ok {
+		name = d.Name
+	}
+	switch e.Result {
+	case DiameterMissingAvp:
+		return "missing AVP " + name
+	case DiameterAvpOccursTooManyTimes:
+		return "AVP " + name + " occurs too many times"
+	case DiameterAvpNotAllowed:
+		return "AVP " + name + " is not allowed"
+	}
+	return "invalid AVP " + name
+}
+
+// FailedAVP returns value of Failed-AVP
+func (e GrammarError) FailedAVP() []RawAVP {
+	return []RawAVP{e.AVP}
+}
+
+// CommandGrammar returns grammar of the command in dictionary
+func CommandGrammar(appID, code uint32, req bool) (Grammar, bool) {
+	c, ok := LookupCommand(appID, code)
+	if !ok {
+		return nil, false
+	}
+	if req {
+		return Grammar(c.Request), true
+	}
+	return Grammar(c.Answer), true
+}
+
+// CheckGrammar check AVPs in the message with command grammar in dictionary.
+// It returns nil when the command is not defined in dictionary.
+func CheckGrammar(m RawMsg) error {
+	if g, ok := CommandGrammar(m.AppID, m.Code, m.FlgR); ok {
+		return g.Check(m.AVP)
+	}
+	return nil
+}
+
+// CheckUnsupportedAVP returns UnsupportedAVP error when the message has
+// AVP with M-bit that is not recognised for the command.
+// AVP in grammar of the command is recognised.
+// Any AVP in dictionary is recognised when the grammar has *[ AVP ]
+// or the command is not defined in dictionary.
+func CheckUnsupportedAVP(m RawMsg) error {
+	var known map[dictAVPKey]bool
+	if g, ok := CommandGrammar(m.AppID, m.Code, m.FlgR); ok {
+		known = make(map[dictAVPKey]bool, len(g))
+		for _, r := range g {
+			if r.Name == "AVP" {
+				known = nil
+				break
+			}
+			if d, ok := LookupAVPByName(r.Name); ok {
+				known[dictAVPKey{code: d.Code, venID: d.VenID}] = true
+			}
+		}
+	}
+	for _, a := range m.AVP {
+		if !a.FlgM {
+			continue
+		}
+		if _, ok := LookupAVP(a.Code, a.VenID); !ok {
+			return UnsupportedAVP{AVP: a}
+		} else if known != nil && !known[dictAVPKey{code: a.Code, venID: a.VenID}] {
+			return UnsupportedAVP{AVP: a}
+		}
+	}
+	return nil
+}
+
+/*
+ParseGrammar make Grammar from command ABNF text of RFC 6733, for example
+
+	<SRR> ::= < Diameter Header: 8388647, REQ, PXY, 16777312 >
+	          < Session-Id >
+	          { Origin-Host }
+	          [ Destination-Host ]
+	        * [ AVP ]
+
+Header definition and comment that starts with "//" are ignored.
+*/
+func ParseGrammar(s string) (Grammar, error) {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if j := strings.Index(l, "//"); j >= 0 {
+			lines[i] = l[:j]
+		}
+	}
+	s = strings.Join(lines, " ")
+	if i := strings.Index(s, "::="); i >= 0 {
+		s = s[i+3:]
+		if i = strings.Index(s, "Diameter Header"); i >= 0 {
+			if j := strings.Index(s[i:], ">"); j >= 0 {
+				s = s[i+j+1:]
+			}
+		}
+	}
+
+	g := Grammar{}
+	for s = strings.TrimSpace(s); len(s) != 0; s = strings.TrimSpace(s) {
+		i := strings.IndexAny(s, "<{[")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid grammar text: %s", s)
+		}
+		q := strings.Replace(s[:i], " ", "", -1)
+		var end string
+		r := DictRule{Min: 0, Max: 1}
+		switch s[i] {
+		case '<':
+			end, r.Fixed, r.Min = ">", true, 1
+		case '{':
+			end, r.Min = "}", 1
+		case '[':
+			end = "]"
+		}
+		j := strings.Index(s[i:], end)
+		if j < 0 {
+			return nil, fmt.Errorf("unterminated AVP name: %s", s)
+		}
+		r.Name = strings.TrimSpace(s[i+1 : i+j])
+		s = s[i+j+1:]
+
+		if len(q) != 0 {
+			min, max := q, q
+			if k := strings.Index(q, "*"); k >= 0 {
+				min, max = q[:k], q[k+1:]
+				r.Max = -1
+			}
+			var e error
+			if len(min) != 0 {
+				if r.Min, e = strconv.Atoi(min); e != nil {
+					return nil, fmt.Errorf("invalid qualifier %s of %s", q, r.Name)
+				}
+			}
+			if len(max) != 0 {
+				if r.Max, e = strconv.Atoi(max); e != nil {
+					return nil, fmt.Errorf("invalid qualifier %s of %s", q, r.Name)
+				}
+			}
+		}
+		g = append(g, r)
+	}
+	return g, nil
+}
+
+// Check AVPs with the grammar.
+// AVP rule is checked in order of fixed position, occurrence and
+// allowed AVP, and first violation is returned as GrammarError.
+// Rule of AVP that is not defined in dictionary is ignored.
+// AVPs in Grouped AVP are also checked with the rule in dictionary.
+func (g Grammar) Check(avp []RawAVP) error {
+	type rule struct {
+		DictRule
+		def DictAVP
+	}
+	rules := make([]rule, 0, len(g))
+	wildcard := false
+	for _, r := range g {
+		if r.Name == "AVP" {
+			wildcard = r.Max != 0
+			continue
+		}
+		if d, ok := LookupAVPByName(r.Name); ok {
+			rules = append(rules, rule{DictRule: r, def: d})
+		}
+	}
+
+	pos := 0
+	for _, r := range rules {
+		if !r.Fixed {
+			continue
+		}
+		for n := 0; n < r.Min; n++ {
+			if pos >= len(avp) || avp[pos].Code != r.def.Code ||
+				avp[pos].VenID != r.def.VenID {
+				return GrammarError{
+					Result: DiameterMissingAvp, AVP: exampleAVP(r.def)}
+			}
+			pos++
+		}
+	}
+
+	for _, r := range rules {
+		n := 0
+		for _, a := range avp {
+			if a.Code != r.def.Code || a.VenID != r.def.VenID {
+				continue
+			}
+			n++
+			if r.Max == 0 {
+				return GrammarError{Result: DiameterAvpNotAllowed, AVP: a}
+			}
+			if r.Max > 0 && n > r.Max {
+				return GrammarError{Result: DiameterAvpOccursTooManyTimes, AVP: a}
+			}
+			if len(r.def.Group) == 0 {
+				continue
+			}
+			var sub []RawAVP
+			if e := a.Decode(&sub); e != nil {
+				return InvalidAVP(DiameterInvalidAvpValue)
+			}
+			if e := Grammar(r.def.Group).Check(sub); e != nil {
+				if ge, ok := e.(GrammarError); ok {
+					ga := RawAVP{Code: a.Code, VenID: a.VenID,
+						FlgV: a.FlgV, FlgM: a.FlgM, FlgP: a.FlgP}
+					ga.Encode([]RawAVP{ge.AVP})
+					ge.AVP = ga
+					e = ge
+				}
+				return e
+			}
+		}
+		if n < r.Min {
+			return GrammarError{Result: DiameterMissingAvp, AVP: exampleAVP(r.def)}
+		}
+	}
+
+	if wildcard {
+		return nil
+	}
+	for _, a := range avp {
+		known := false
+		for _, r := range rules {
+			if a.Code == r.def.Code && a.VenID == r.def.VenID {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return GrammarError{Result: DiameterAvpNotAllowed, AVP: a}
+		}
+	}
+	return nil
+}
+
+// exampleAVP returns missing AVP that has zero value of minimum length
+func exampleAVP(d DictAVP) RawAVP {
+	a := RawAVP{Code: d.Code, VenID: d.VenID,
+		FlgV: d.VenID != 0, FlgM: d.Mandatory == FlagMust,
+		FlgP: d.Protected == FlagMust}
+	switch d.Type {
+	case "Integer32", "Unsigned32", "Float32", "Enumerated", "Time":
+		a.data = make([]byte, 4)
+	case "Integer64", "Unsigned64", "Float64":
+		a.data = make([]byte, 8)
+	default:
+		a.data = []byte{}
+	}
+	return a
+}
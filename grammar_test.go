@@ -0,0 +1,48 @@
+package diameter
+
+import (
+	"net"
+	"testing"
+)
+
+func TestMessageGrammar(t *testing.T) {
+	oh, or := Identity("mme.example.com"), Identity("example.com")
+	apps := map[uint32][]uint32{0: {0}}
+	ip := []net.IP{net.ParseIP("192.0.2.1")}
+
+	req := GenericReq{Code: 316, VenID: 10415, AppID: 16777251,
+		OriginHost: oh, OriginRealm: or, DestinationRealm: "example.net"}
+	req.AVP = []RawAVP{
+		testPathAVP(t, "User-Name", "440101234567890"),
+		testPathAVP(t, "RAT-Type", Enumerated(1004)),
+		testPathAVP(t, "ULR-Flags", uint32(0)),
+		testPathAVP(t, "Visited-PLMN-Id", []byte{0x44, 0xf0, 0x10})}
+
+	tests := []struct {
+		name string
+		msg  interface{ ToRaw(string) RawMsg }
+	}{
+		{"CER", CER{OriginHost: oh, OriginRealm: or, HostIPAddress: ip,
+			VendorID: 1, ProductName: "test", ApplicationID: apps}},
+		{"CEA", CEA{ResultCode: DiameterSuccess, OriginHost: oh, OriginRealm: or,
+			HostIPAddress: ip, VendorID: 1, ProductName: "test", ApplicationID: apps}},
+		{"DPR", DPR{OriginHost: oh, OriginRealm: or, DisconnectCause: Rebooting}},
+		{"DPA", DPA{ResultCode: DiameterSuccess, OriginHost: oh, OriginRealm: or}},
+		{"DWR", DWR{OriginHost: oh, OriginRealm: or, OriginStateID: 1}},
+		{"DWA", DWA{ResultCode: DiameterSuccess, OriginHost: oh, OriginRealm: or}},
+		{"GenericReq", req},
+		{"GenericAns", req.Failed(DiameterSuccess)},
+		{"GenericAns with error", req.Failed(DiameterUnableToComply)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.msg.ToRaw("mme.example.com;1;1")
+			if _, ok := CommandGrammar(m.AppID, m.Code, m.FlgR); !ok {
+				t.Fatalf("no grammar of the command in dictionary")
+			}
+			if e := CheckGrammar(m); e != nil {
+				t.Errorf("grammar error: %s", e)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package diameter
+
+// GroupedAVP is AVPs in Grouped AVP data
+type GroupedAVP []RawAVP
+
+// Get returns first AVP of the code and vendor
+func (g GroupedAVP) Get(code, venID uint32) (RawAVP, bool) {
+	for _, a := range g {
+		if a.Code == code && a.VenID == venID {
+			return a, true
+		}
+	}
+	return RawAVP{}, false
+}
+
+// GetAll returns all AVPs of the code and vendor
+func (g GroupedAVP) GetAll(code, venID uint32) []RawAVP {
+	var r []RawAVP
+	for _, a := range g {
+		if a.Code == code && a.VenID == venID {
+			r = append(r, a)
+		}
+	}
+	return r
+}
+
+// Add appends AVPs
+func (g *GroupedAVP) Add(a ...RawAVP) {
+	*g = append(*g, a...)
+}
+
+// Remove deletes all AVPs of the code and vendor,
+// and returns number of deleted AVPs
+func (g *GroupedAVP) Remove(code, venID uint32) int {
+	r := make(GroupedAVP, 0, len(*g))
+	n := 0
+	for _, a := range *g {
+		if a.Code == code && a.VenID == venID {
+			n++
+		} else {
+			r = append(r, a)
+		}
+	}
+	*g = r
+	return n
+}
+
+// Replace set the AVP to position of first AVP that has same code and vendor,
+// and deletes other AVPs of the code and vendor.
+// The AVP is appended when no AVP has the code and vendor,
+// and it returns false in that case.
+func (g *GroupedAVP) Replace(a RawAVP) bool {
+	r := make(GroupedAVP, 0, len(*g))
+	found := false
+	for _, o := range *g {
+		if o.Code != a.Code || o.VenID != a.VenID {
+			r = append(r, o)
+		} else if !found {
+			r = append(r, a)
+			found = true
+		}
+	}
+	if !found {
+		r = append(r, a)
+	}
+	*g = r
+	return found
+}
+
+// Walk calls f for each AVP in depth-first order.
+// AVP that is Grouped type in dictionary is walked recursively,
+// and path is the Grouped AVPs that contain the AVP.
+// Walk stops when f returns false.
+func (g GroupedAVP) Walk(f func(path []RawAVP, a RawAVP) bool) {
+	walkAVP(nil, g, f)
+}
+
+func walkAVP(path []RawAVP, g GroupedAVP, f func([]RawAVP, RawAVP) bool) bool {
+	for _, a := range g {
+		if !f(path, a) {
+			return false
+		}
+		if d, ok := LookupAVP(a.Code, a.VenID); !ok || d.Type != "Grouped" {
+			continue
+		}
+		var sub GroupedAVP
+		if e := a.Decode(&sub); e != nil {
+			continue
+		}
+		p := make([]RawAVP, len(path), len(path)+1)
+		copy(p, path)
+		if !walkAVP(append(p, a), sub, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// Get returns first AVP of the code and vendor in the message
+func (m RawMsg) Get(code, venID uint32) (RawAVP, bool) {
+	return GroupedAVP(m.AVP).Get(code, venID)
+}
+
+// GetAll returns all AVPs of the code and vendor in the message
+func (m RawMsg) GetAll(code, venID uint32) []RawAVP {
+	return GroupedAVP(m.AVP).GetAll(code, venID)
+}
+
+// Add appends AVPs to the message
+func (m *RawMsg) Add(a ...RawAVP) {
+	m.AVP = append(m.AVP, a...)
+}
+
+// Remove deletes all AVPs of the code and vendor in the message,
+// and returns number of deleted AVPs
+func (m *RawMsg) Remove(code, venID uint32) int {
+	g := GroupedAVP(m.AVP)
+	n := g.Remove(code, venID)
+	m.AVP = g
+	return n
+}
+
+// Replace set the AVP to the message as GroupedAVP.Replace
+func (m *RawMsg) Replace(a RawAVP) bool {
+	g := GroupedAVP(m.AVP)
+	r := g.Replace(a)
+	m.AVP = g
+	return r
+}
+
+// Walk calls f for each AVP in the message as GroupedAVP.Walk
+func (m RawMsg) Walk(f func(path []RawAVP, a RawAVP) bool) {
+	GroupedAVP(m.AVP).Walk(f)
+}
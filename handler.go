@@ -106,6 +106,15 @@ func match(a, b []uint32) []uint32 {
 	return r
 }
 
+// HandlePeerReboot is called when reboot of the peer is detected
+// by change of Origin-State-Id in CER, CEA, DWR or DWA.
+// Sessions that are bound to the peer should be purged or re-established.
+// It is called in event handler, so it must not be blocked.
+var HandlePeerReboot = defaultHandlePeerReboot
+
+func defaultHandlePeerReboot(h Identity, c *Conn) {
+}
+
 // HandleCEA is CEA handler function
 var HandleCEA = defaultHandleCEA
 
@@ -135,6 +144,8 @@ func defaultHandleDWR(r DWR, c *Conn) DWA {
 		OriginStateID: StateID}
 	if c.Peer.Host != r.OriginHost || c.Peer.Realm != r.OriginRealm {
 		dwa.ResultCode = DiameterUnknownPeer
+	} else if LoadReport {
+		dwa.Load = []Load{{Type: PeerLoad, Value: LocalLoad(c), SourceID: Host}}
 	}
 
 	return dwa
@@ -144,16 +155,19 @@ func defaultHandleDWR(r DWR, c *Conn) DWA {
 var HandleDWA = defaultHandleDWA
 
 func defaultHandleDWA(r DWA, c *Conn) {
+	for _, l := range r.Load {
+		updateLoad(c, l)
+	}
 }
 
-// MakeDPR returns new DWR
+// MakeDPR returns new DPR
 var MakeDPR = defaultMakeDPR
 
 func defaultMakeDPR(c *Conn) DPR {
 	return DPR{
 		OriginHost:      Host,
 		OriginRealm:     Realm,
-		DisconnectCause: Rebooting}
+		DisconnectCause: c.cause}
 }
 
 // HandleDPR is DPR handler function
@@ -3,6 +3,7 @@ package diameter
 import (
 	"bytes"
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 
@@ -20,7 +21,9 @@ const (
 // Identity is identity of Diameter protocol
 type Identity string
 
-// ParseIdentity parse Diamter identity form string
+// ParseIdentity parse Diamter identity form string.
+// Internationalized name must be in ASCII form such as "xn--bcher-kva",
+// and trailing dot of absolute name is removed.
 func ParseIdentity(str string) (id Identity, e error) {
 	t := abnf.ParseString(str, _identity())
 	if t == nil {
@@ -65,7 +68,9 @@ type URI struct {
 	Protocol  string
 }
 
-// ParseURI parse Diamter URI form string
+// ParseURI parse Diamter URI form string.
+// Host of the URI can also be IP address,
+// and IPv6 address is enclosed in "[" and "]".
 func ParseURI(str string) (uri URI, e error) {
 	t := abnf.ParseString(str, _uri())
 	if t == nil {
@@ -73,6 +78,10 @@ func ParseURI(str string) (uri URI, e error) {
 	} else {
 		uri.Scheme = string(t.Child(idSCHEME).V)
 		uri.Fqdn = Identity(t.Child(idFQDN).V)
+		if strings.Contains(string(uri.Fqdn), ":") && net.ParseIP(string(uri.Fqdn)) == nil {
+			e = fmt.Errorf("Invalid IPv6 address %s", uri.Fqdn)
+			return
+		}
 		p, _ := strconv.ParseInt(string(t.Child(idPORT).V), 10, 32)
 		uri.Port = int(p)
 		uri.Transport = string(t.Child(idTRANSPORT).V)
@@ -85,7 +94,11 @@ func (d URI) String() string {
 	var b bytes.Buffer
 	b.WriteString(d.Scheme)
 	b.WriteString("://")
-	b.WriteString(string(d.Fqdn))
+	if strings.Contains(string(d.Fqdn), ":") {
+		b.WriteString("[" + string(d.Fqdn) + "]")
+	} else {
+		b.WriteString(string(d.Fqdn))
+	}
 	if d.Port != 0 {
 		b.WriteString(":")
 		b.WriteString(strconv.Itoa(d.Port))
@@ -102,7 +115,7 @@ func (d URI) String() string {
 }
 
 func _uri() abnf.Rule {
-	return abnf.C(_scheme(), _fqdn(), abnf.O(_port()), abnf.O(_transport()), abnf.O(_protocol()), abnf.EOF())
+	return abnf.C(_scheme(), _host(), abnf.O(_port()), abnf.O(_transport()), abnf.O(_protocol()), abnf.EOF())
 }
 
 func _scheme() abnf.Rule {
@@ -112,7 +125,13 @@ func _scheme() abnf.Rule {
 }
 
 func _identity() abnf.Rule {
-	return abnf.C(_fqdn(), abnf.EOF())
+	return abnf.C(_fqdn(), abnf.O(abnf.V('.')), abnf.EOF())
+}
+
+func _host() abnf.Rule {
+	return abnf.A(
+		abnf.C(_fqdn(), abnf.O(abnf.V('.'))),
+		abnf.C(abnf.V('['), abnf.K(abnf.RV(2, -1, abnf.A(abnf.ALPHANUM(), abnf.V(':'), abnf.V('.'))), idFQDN), abnf.V(']')))
 }
 
 func _fqdn() abnf.Rule {
@@ -124,7 +143,7 @@ func _label() abnf.Rule {
 }
 
 func _ldhstr() abnf.Rule {
-	return abnf.A(abnf.ALPHANUM(), abnf.C(abnf.V('-'), abnf.ALPHANUM()))
+	return abnf.C(abnf.R0(abnf.V('-')), abnf.ALPHANUM())
 }
 
 func _port() abnf.Rule {
@@ -0,0 +1,379 @@
+package diameter
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+/*
+IPFilterRule is IPFilterRule format AVP value of RFC 6733 section 4.3.1.
+
+	action dir proto from src to dst [options]
+
+Proto is IP protocol number, or -1 for any protocol ("ip").
+*/
+type IPFilterRule struct {
+	Permit bool // permit or deny
+	Out    bool // out or in
+	Proto  int
+	Src    IPFilterAddr
+	Dst    IPFilterAddr
+
+	Frag        bool
+	IPOptions   []IPFilterOption
+	TCPOptions  []IPFilterOption
+	Established bool
+	Setup       bool
+	TCPFlags    []IPFilterOption
+	ICMPTypes   []IPFilterRange
+}
+
+// IPFilterAddr is src or dst of IPFilterRule.
+// Net is nil when Any or Assigned is true.
+type IPFilterAddr struct {
+	Not      bool
+	Any      bool
+	Assigned bool
+	Net      *net.IPNet
+	Ports    []IPFilterRange
+}
+
+// IPFilterRange is range of port or ICMP type
+type IPFilterRange struct {
+	Min uint16
+	Max uint16
+}
+
+// IPFilterOption is item of ipoptions, tcpoptions and tcpflags
+type IPFilterOption struct {
+	Not  bool
+	Name string
+}
+
+var (
+	ipOptionNames  = map[string]bool{"ssrr": true, "lsrr": true, "rr": true, "ts": true}
+	tcpOptionNames = map[string]bool{
+		"mss": true, "window": true, "sack": true, "ts": true, "cc": true}
+	tcpFlagNames = map[string]bool{
+		"fin": true, "syn": true, "rst": true, "psh": true, "ack": true, "urg": true}
+	protoNames = map[string]int{
+		"ip": -1, "icmp": 1, "tcp": 6, "udp": 17, "ipv6-icmp": 58, "sctp": 132}
+)
+
+// ParseIPFilterRule parse IPFilterRule text
+func ParseIPFilterRule(s string) (r IPFilterRule, e error) {
+	t := strings.Fields(s)
+	if len(t) < 7 {
+		return r, fmt.Errorf("too short IPFilterRule: %s", s)
+	}
+
+	switch t[0] {
+	case "permit":
+		r.Permit = true
+	case "deny":
+	default:
+		return r, fmt.Errorf("invalid action %s", t[0])
+	}
+	switch t[1] {
+	case "in":
+	case "out":
+		r.Out = true
+	default:
+		return r, fmt.Errorf("invalid direction %s", t[1])
+	}
+	if p, ok := protoNames[strings.ToLower(t[2])]; ok {
+		r.Proto = p
+	} else if p, err := strconv.ParseUint(t[2], 10, 8); err == nil {
+		r.Proto = int(p)
+	} else {
+		return r, fmt.Errorf("invalid protocol %s", t[2])
+	}
+
+	if t[3] != "from" {
+		return r, fmt.Errorf("from is required")
+	}
+	t = t[4:]
+	if r.Src, t, e = parseIPFilterAddr(t); e != nil {
+		return
+	}
+	if len(t) == 0 || t[0] != "to" {
+		return r, fmt.Errorf("to is required")
+	}
+	if r.Dst, t, e = parseIPFilterAddr(t[1:]); e != nil {
+		return
+	}
+
+	for len(t) != 0 {
+		o := t[0]
+		t = t[1:]
+		switch o {
+		case "frag":
+			r.Frag = true
+		case "established":
+			r.Established = true
+		case "setup":
+			r.Setup = true
+		case "ipoptions", "tcpoptions", "tcpflags":
+			if len(t) == 0 {
+				return r, fmt.Errorf("no spec of %s", o)
+			}
+			names := ipOptionNames
+			if o == "tcpoptions" {
+				names = tcpOptionNames
+			} else if o == "tcpflags" {
+				names = tcpFlagNames
+			}
+			var l []IPFilterOption
+			if l, e = parseIPFilterOptions(t[0], names); e != nil {
+				return
+			}
+			switch o {
+			case "ipoptions":
+				r.IPOptions = append(r.IPOptions, l...)
+			case "tcpoptions":
+				r.TCPOptions = append(r.TCPOptions, l...)
+			case "tcpflags":
+				r.TCPFlags = append(r.TCPFlags, l...)
+			}
+			t = t[1:]
+		case "icmptypes":
+			if len(t) == 0 {
+				return r, fmt.Errorf("no types of icmptypes")
+			}
+			var l []IPFilterRange
+			if l, e = parseIPFilterRanges(t[0], 255); e != nil {
+				return
+			}
+			r.ICMPTypes = append(r.ICMPTypes, l...)
+			t = t[1:]
+		default:
+			return r, fmt.Errorf("unknown option %s", o)
+		}
+	}
+	return
+}
+
+func parseIPFilterAddr(t []string) (a IPFilterAddr, rest []string, e error) {
+	if len(t) == 0 {
+		return a, t, fmt.Errorf("no address")
+	}
+	s := t[0]
+	if s == "!" {
+		if t = t[1:]; len(t) == 0 {
+			return a, t, fmt.Errorf("no address")
+		}
+		s = "!" + t[0]
+	}
+	t = t[1:]
+	if strings.HasPrefix(s, "!") {
+		a.Not = true
+		s = s[1:]
+	}
+
+	switch s {
+	case "any":
+		a.Any = true
+	case "assigned":
+		a.Assigned = true
+	default:
+		if strings.Contains(s, "/") {
+			var ip net.IP
+			if ip, a.Net, e = net.ParseCIDR(s); e != nil {
+				return a, t, fmt.Errorf("invalid address %s", s)
+			}
+			if ip.To4() != nil {
+				a.Net.IP = a.Net.IP.To4()
+			}
+		} else if ip := net.ParseIP(s); ip == nil {
+			return a, t, fmt.Errorf("invalid address %s", s)
+		} else if v4 := ip.To4(); v4 != nil {
+			a.Net = &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}
+		} else {
+			a.Net = &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+		}
+	}
+
+	if len(t) != 0 && len(t[0]) != 0 && t[0][0] >= '0' && t[0][0] <= '9' {
+		if a.Ports, e = parseIPFilterRanges(t[0], 65535); e != nil {
+			return
+		}
+		t = t[1:]
+	}
+	return a, t, nil
+}
+
+func parseIPFilterRanges(s string, max uint64) (l []IPFilterRange, e error) {
+	for _, p := range strings.Split(s, ",") {
+		min, top := p, p
+		if i := strings.Index(p, "-"); i >= 0 {
+			min, top = p[:i], p[i+1:]
+		}
+		var r IPFilterRange
+		v1, e1 := strconv.ParseUint(min, 10, 16)
+		v2, e2 := strconv.ParseUint(top, 10, 16)
+		if e1 != nil || e2 != nil || v1 > v2 || v2 > max {
+			return nil, fmt.Errorf("invalid range %s", p)
+		}
+		r.Min, r.Max = uint16(v1), uint16(v2)
+		l = append(l, r)
+	}
+	return
+}
+
+func parseIPFilterOptions(s string, names map[string]bool) (l []IPFilterOption, e error) {
+	for _, p := range strings.Split(s, ",") {
+		o := IPFilterOption{}
+		if strings.HasPrefix(p, "!") {
+			o.Not = true
+			p = p[1:]
+		}
+		if !names[p] {
+			return nil, fmt.Errorf("invalid option spec %s", p)
+		}
+		o.Name = p
+		l = append(l, o)
+	}
+	return
+}
+
+// String returns canonical text of the rule
+func (r IPFilterRule) String() string {
+	w := new(bytes.Buffer)
+	if r.Permit {
+		w.WriteString("permit ")
+	} else {
+		w.WriteString("deny ")
+	}
+	if r.Out {
+		w.WriteString("out ")
+	} else {
+		w.WriteString("in ")
+	}
+	if r.Proto < 0 {
+		w.WriteString("ip")
+	} else {
+		w.WriteString(strconv.Itoa(r.Proto))
+	}
+	fmt.Fprintf(w, " from %s to %s", r.Src, r.Dst)
+
+	if r.Frag {
+		w.WriteString(" frag")
+	}
+	if len(r.IPOptions) != 0 {
+		fmt.Fprintf(w, " ipoptions %s", ipFilterOptionsString(r.IPOptions))
+	}
+	if len(r.TCPOptions) != 0 {
+		fmt.Fprintf(w, " tcpoptions %s", ipFilterOptionsString(r.TCPOptions))
+	}
+	if r.Established {
+		w.WriteString(" established")
+	}
+	if r.Setup {
+		w.WriteString(" setup")
+	}
+	if len(r.TCPFlags) != 0 {
+		fmt.Fprintf(w, " tcpflags %s", ipFilterOptionsString(r.TCPFlags))
+	}
+	if len(r.ICMPTypes) != 0 {
+		fmt.Fprintf(w, " icmptypes %s", ipFilterRangesString(r.ICMPTypes))
+	}
+	return w.String()
+}
+
+func (a IPFilterAddr) String() string {
+	w := new(bytes.Buffer)
+	if a.Not {
+		w.WriteString("!")
+	}
+	switch {
+	case a.Any:
+		w.WriteString("any")
+	case a.Assigned:
+		w.WriteString("assigned")
+	case a.Net == nil:
+		w.WriteString("any")
+	default:
+		if o, b := a.Net.Mask.Size(); o == b {
+			w.WriteString(a.Net.IP.String())
+		} else {
+			w.WriteString(a.Net.String())
+		}
+	}
+	if len(a.Ports) != 0 {
+		fmt.Fprintf(w, " %s", ipFilterRangesString(a.Ports))
+	}
+	return w.String()
+}
+
+func ipFilterRangesString(l []IPFilterRange) string {
+	s := make([]string, len(l))
+	for i, r := range l {
+		if r.Min == r.Max {
+			s[i] = strconv.Itoa(int(r.Min))
+		} else {
+			s[i] = fmt.Sprintf("%d-%d", r.Min, r.Max)
+		}
+	}
+	return strings.Join(s, ",")
+}
+
+func ipFilterOptionsString(l []IPFilterOption) string {
+	s := make([]string, len(l))
+	for i, o := range l {
+		if o.Not {
+			s[i] = "!" + o.Name
+		} else {
+			s[i] = o.Name
+		}
+	}
+	return strings.Join(s, ",")
+}
+
+// FiveTuple is packet information that is matched with IPFilterRule.
+// Assigned is address that is assigned to the terminal,
+// and nil Assigned matches any address for "assigned" of the rule.
+type FiveTuple struct {
+	Proto    uint8
+	Src      net.IP
+	SrcPort  uint16
+	Dst      net.IP
+	DstPort  uint16
+	Assigned net.IP
+}
+
+// Match returns true when the packet matches with protocol, address and
+// port of the rule. Options of the rule are not evaluated.
+// Direction of the rule should be checked by caller.
+func (r IPFilterRule) Match(p FiveTuple) bool {
+	if r.Proto >= 0 && r.Proto != int(p.Proto) {
+		return false
+	}
+	return r.Src.match(p.Src, p.SrcPort, p.Assigned) &&
+		r.Dst.match(p.Dst, p.DstPort, p.Assigned)
+}
+
+func (a IPFilterAddr) match(ip net.IP, port uint16, assigned net.IP) bool {
+	m := true
+	switch {
+	case a.Any:
+	case a.Assigned:
+		m = assigned == nil || assigned.Equal(ip)
+	case a.Net != nil:
+		m = a.Net.Contains(ip)
+	}
+	if a.Not {
+		m = !m
+	}
+	if !m || len(a.Ports) == 0 {
+		return m
+	}
+	for _, r := range a.Ports {
+		if port >= r.Min && port <= r.Max {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package diameter
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestParseIPFilterRule(t *testing.T) {
+	tests := []struct {
+		rule  string
+		canon string
+	}{
+		{"permit in ip from any to any",
+			"permit in ip from any to any"},
+		{"deny out tcp from 192.0.2.1 to 198.51.100.0/24 80",
+			"deny out 6 from 192.0.2.1 to 198.51.100.0/24 80"},
+		{"permit out 17 from assigned 1024-65535 to any 53,5353",
+			"permit out 17 from assigned 1024-65535 to any 53,5353"},
+		{"permit in ip from ! 10.0.0.0/8 to !assigned",
+			"permit in ip from !10.0.0.0/8 to !assigned"},
+		{"permit in ip from !any to 2001:db8::/32 443",
+			"permit in ip from !any to 2001:db8::/32 443"},
+		{"permit out ipv6-icmp from 2001:db8::1 to any icmptypes 128,129,1-4",
+			"permit out 58 from 2001:db8::1 to any icmptypes 128,129,1-4"},
+		{"permit in ip from any to any frag",
+			"permit in ip from any to any frag"},
+		{"deny in ip from any to any ipoptions ssrr,!lsrr,rr,!ts",
+			"deny in ip from any to any ipoptions ssrr,!lsrr,rr,!ts"},
+		{"deny in tcp from any to any tcpoptions mss,!window,sack,ts,!cc",
+			"deny in 6 from any to any tcpoptions mss,!window,sack,ts,!cc"},
+		{"permit in tcp from any to any established",
+			"permit in 6 from any to any established"},
+		{"deny in tcp from any to any setup",
+			"deny in 6 from any to any setup"},
+		{"deny in tcp from any to any tcpflags syn,!ack,fin,rst,psh,urg",
+			"deny in 6 from any to any tcpflags syn,!ack,fin,rst,psh,urg"},
+		{"permit out sctp from 192.0.2.0/24 3868 to any 3868-3870 frag setup established",
+			"permit out 132 from 192.0.2.0/24 3868 to any 3868-3870 frag established setup"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, e := ParseIPFilterRule(tt.rule)
+			if e != nil {
+				t.Fatalf("parse failed: %s", e)
+			}
+			if s := r.String(); s != tt.canon {
+				t.Fatalf("string %q, want %q", s, tt.canon)
+			}
+			r2, e := ParseIPFilterRule(tt.canon)
+			if e != nil {
+				t.Fatalf("parse of canonical text failed: %s", e)
+			}
+			if !reflect.DeepEqual(r, r2) {
+				t.Errorf("parsed rule is changed\n%+v\n%+v", r, r2)
+			}
+		})
+	}
+}
+
+func TestParseIPFilterRuleInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"permit in ip from any to",
+		"allow in ip from any to any",
+		"permit up ip from any to any",
+		"permit in xyz from any to any",
+		"permit in 256 from any to any",
+		"permit in ip src any to any",
+		"permit in ip from any dst any",
+		"permit in ip from 192.0.2.256 to any",
+		"permit in ip from 192.0.2.0/33 to any",
+		"permit in ip from ! to any",
+		"permit in tcp from any 80- to any",
+		"permit in tcp from any -80 to any",
+		"permit in tcp from any 90-80 to any",
+		"permit in tcp from any 65536 to any",
+		"permit in ip from any to any unknown",
+		"permit in ip from any to any ipoptions",
+		"permit in ip from any to any ipoptions mss",
+		"permit in ip from any to any tcpoptions rr",
+		"permit in ip from any to any tcpflags syn,fun",
+		"permit in ip from any to any icmptypes 256",
+		"permit in ip from any to any icmptypes",
+	} {
+		if r, e := ParseIPFilterRule(s); e == nil {
+			t.Errorf("parsed invalid rule %q as %q", s, r)
+		}
+	}
+}
+
+func TestIPFilterRuleMatch(t *testing.T) {
+	ip := net.ParseIP
+	tests := []struct {
+		rule  string
+		p     FiveTuple
+		match bool
+	}{
+		{"permit out ip from any to any",
+			FiveTuple{Proto: 6, Src: ip("192.0.2.1"), Dst: ip("2001:db8::1")}, true},
+		{"permit out tcp from any to any",
+			FiveTuple{Proto: 17, Src: ip("192.0.2.1"), Dst: ip("192.0.2.2")}, false},
+		{"permit out 17 from 192.0.2.0/24 to any 53",
+			FiveTuple{Proto: 17, Src: ip("192.0.2.10"), Dst: ip("198.51.100.1"), DstPort: 53}, true},
+		{"permit out 17 from 192.0.2.0/24 to any 53",
+			FiveTuple{Proto: 17, Src: ip("192.0.3.10"), Dst: ip("198.51.100.1"), DstPort: 53}, false},
+		{"permit out 17 from 192.0.2.0/24 to any 53",
+			FiveTuple{Proto: 17, Src: ip("192.0.2.10"), Dst: ip("198.51.100.1"), DstPort: 54}, false},
+		{"permit out tcp from any 1024-65535 to any 80,443",
+			FiveTuple{Proto: 6, Src: ip("192.0.2.1"), SrcPort: 40000, Dst: ip("192.0.2.2"), DstPort: 443}, true},
+		{"permit out tcp from any 1024-65535 to any 80,443",
+			FiveTuple{Proto: 6, Src: ip("192.0.2.1"), SrcPort: 1023, Dst: ip("192.0.2.2"), DstPort: 443}, false},
+		{"permit in ip from 2001:db8::/32 to 2001:db8::1",
+			FiveTuple{Src: ip("2001:db8:1::1"), Dst: ip("2001:db8::1")}, true},
+		{"permit in ip from 2001:db8::/32 to 2001:db8::1",
+			FiveTuple{Src: ip("2001:db9::1"), Dst: ip("2001:db8::1")}, false},
+		{"permit in ip from 192.0.2.0/24 to any",
+			FiveTuple{Src: ip("::ffff:192.0.2.1"), Dst: ip("192.0.2.2")}, true},
+		{"permit in ip from 192.0.2.0/24 to any",
+			FiveTuple{Src: ip("2001:db8::1"), Dst: ip("192.0.2.2")}, false},
+		{"permit out ip from assigned to any",
+			FiveTuple{Src: ip("10.0.0.1"), Dst: ip("192.0.2.2"), Assigned: ip("10.0.0.1")}, true},
+		{"permit out ip from assigned to any",
+			FiveTuple{Src: ip("10.0.0.2"), Dst: ip("192.0.2.2"), Assigned: ip("10.0.0.1")}, false},
+		{"permit out ip from assigned to any",
+			FiveTuple{Src: ip("10.0.0.2"), Dst: ip("192.0.2.2")}, true},
+		{"permit out ip from !assigned to any",
+			FiveTuple{Src: ip("10.0.0.2"), Dst: ip("192.0.2.2"), Assigned: ip("10.0.0.1")}, true},
+		{"deny in ip from !10.0.0.0/8 to any",
+			FiveTuple{Src: ip("192.0.2.1"), Dst: ip("10.0.0.1")}, true},
+		{"deny in ip from !10.0.0.0/8 to any",
+			FiveTuple{Src: ip("10.1.2.3"), Dst: ip("10.0.0.1")}, false},
+		{"deny in tcp from !10.0.0.0/8 80 to any",
+			FiveTuple{Proto: 6, Src: ip("192.0.2.1"), SrcPort: 8080, Dst: ip("10.0.0.1")}, false},
+		{"deny in ip from !any to any",
+			FiveTuple{Src: ip("192.0.2.1"), Dst: ip("10.0.0.1")}, false},
+	}
+	for _, tt := range tests {
+		r, e := ParseIPFilterRule(tt.rule)
+		if e != nil {
+			t.Fatalf("parse %q failed: %s", tt.rule, e)
+		}
+		if m := r.Match(tt.p); m != tt.match {
+			t.Errorf("match of %q with %+v is %t, want %t", tt.rule, tt.p, m, tt.match)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package diameter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+/*
+JSON format of RawMsg is
+
+	{
+	  "version": 1,
+	  "flags": "RP",
+	  "command": "Capabilities-Exchange-Request",
+	  "code": 257,
+	  "application": 0,
+	  "hop-by-hop": 1,
+	  "end-to-end": 1,
+	  "avp": [ ... ]
+	}
+
+and JSON format of RawAVP is
+
+	{
+	  "code": 264,
+	  "name": "Origin-Host",
+	  "flags": "M",
+	  "vendor": 10415,
+	  "value": "host.example.com"
+	}
+
+Flags are letters of the flag that is set, and command name and AVP name
+are only for reading. Value of AVP is one of
+
+	"value": text or number of ValueString
+	"avp":   list of AVPs in Grouped AVP
+	"data":  base64 encoded data
+
+Value is used when the AVP is in dictionary, and data is used when
+the AVP is not in dictionary or the data is invalid for the data type,
+so that JSON is decoded to same bytes of the message.
+*/
+type jsonMsg struct {
+	Version uint8    `json:"version"`
+	Flags   string   `json:"flags"`
+	Command string   `json:"command,omitempty"`
+	Code    uint32   `json:"code"`
+	AppID   uint32   `json:"application"`
+	HbHID   uint32   `json:"hop-by-hop"`
+	EtEID   uint32   `json:"end-to-end"`
+	AVP     []RawAVP `json:"avp"`
+}
+
+type jsonAVP struct {
+	Code  uint32          `json:"code"`
+	Name  string          `json:"name,omitempty"`
+	Flags string          `json:"flags"`
+	VenID uint32          `json:"vendor,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+	AVP   []RawAVP        `json:"avp,omitempty"`
+	Data  []byte          `json:"data,omitempty"`
+}
+
+// jsonFlags returns letters of the flag that is set
+func jsonFlags(letters string, f ...bool) string {
+	s := ""
+	for i, set := range f {
+		if set {
+			s += letters[i : i+1]
+		}
+	}
+	return s
+}
+
+// MarshalJSON returns JSON of the message
+func (m RawMsg) MarshalJSON() ([]byte, error) {
+	j := jsonMsg{
+		Version: m.Ver,
+		Flags:   jsonFlags("RPET", m.FlgR, m.FlgP, m.FlgE, m.FlgT),
+		Code:    m.Code,
+		AppID:   m.AppID,
+		HbHID:   m.HbHID,
+		EtEID:   m.EtEID,
+		AVP:     m.AVP}
+	if c, ok := LookupCommand(m.AppID, m.Code); ok {
+		j.Command = c.Name
+		if m.FlgR {
+			j.Command += "-Request"
+		} else {
+			j.Command += "-Answer"
+		}
+	}
+	if j.AVP == nil {
+		j.AVP = []RawAVP{}
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON set the message from JSON
+func (m *RawMsg) UnmarshalJSON(b []byte) error {
+	j := jsonMsg{Version: 1}
+	if e := json.Unmarshal(b, &j); e != nil {
+		return e
+	}
+	for _, c := range j.Flags {
+		switch c {
+		case 'R':
+			m.FlgR = true
+		case 'P':
+			m.FlgP = true
+		case 'E':
+			m.FlgE = true
+		case 'T':
+			m.FlgT = true
+		default:
+			return fmt.Errorf("invalid command flag %c", c)
+		}
+	}
+	m.Ver = j.Version
+	m.Code = j.Code
+	m.AppID = j.AppID
+	m.HbHID = j.HbHID
+	m.EtEID = j.EtEID
+	m.AVP = j.AVP
+	return nil
+}
+
+// MarshalJSON returns JSON of the AVP
+func (a RawAVP) MarshalJSON() ([]byte, error) {
+	j := jsonAVP{
+		Code:  a.Code,
+		Flags: jsonFlags("VMP", a.FlgV, a.FlgM, a.FlgP),
+		VenID: a.VenID}
+
+	if d, ok := LookupAVP(a.Code, a.VenID); ok {
+		j.Name = d.Name
+		t := RawAVP{}
+		if d.Type == "Grouped" {
+			var g []RawAVP
+			if a.Decode(&g) == nil && t.Encode(g) == nil && bytes.Equal(t.data, a.data) {
+				j.AVP = g
+			}
+		} else if s, e := valueString(a, d.Type); e != nil {
+		} else if t.setValueString(s, d) != nil || !bytes.Equal(t.data, a.data) {
+		} else if isJSONNumber(d.Type, s) {
+			j.Value = json.RawMessage(s)
+		} else {
+			j.Value, _ = json.Marshal(s)
+		}
+	}
+	if j.Value == nil && j.AVP == nil {
+		j.Data = a.data
+	}
+	return json.Marshal(j)
+}
+
+func isJSONNumber(typ, s string) bool {
+	switch typ {
+	case "Integer32", "Integer64", "Unsigned32", "Unsigned64", "Enumerated":
+		return true
+	case "Float32", "Float64":
+		return !strings.ContainsAny(s, "NI")
+	}
+	return false
+}
+
+// UnmarshalJSON set the AVP from JSON.
+// AVP code and vendor ID can be replaced by AVP name in dictionary.
+func (a *RawAVP) UnmarshalJSON(b []byte) error {
+	var j jsonAVP
+	if e := json.Unmarshal(b, &j); e != nil {
+		return e
+	}
+	*a = RawAVP{Code: j.Code, VenID: j.VenID}
+	if a.Code == 0 && j.Name != "" {
+		d, ok := LookupAVPByName(j.Name)
+		if !ok {
+			return fmt.Errorf("unknown AVP %s", j.Name)
+		}
+		a.Code, a.VenID = d.Code, d.VenID
+	}
+	for _, c := range j.Flags {
+		switch c {
+		case 'V':
+			a.FlgV = true
+		case 'M':
+			a.FlgM = true
+		case 'P':
+			a.FlgP = true
+		default:
+			return fmt.Errorf("invalid AVP flag %c", c)
+		}
+	}
+
+	switch {
+	case j.Value != nil:
+		d, ok := LookupAVP(a.Code, a.VenID)
+		if !ok {
+			return fmt.Errorf("value of unknown AVP %d:%d", a.Code, a.VenID)
+		}
+		s := string(j.Value)
+		if len(s) != 0 && s[0] == '"' {
+			if e := json.Unmarshal(j.Value, &s); e != nil {
+				return e
+			}
+		}
+		return a.setValueString(s, d)
+	case j.AVP != nil:
+		return a.Encode(j.AVP)
+	case j.Data != nil:
+		a.data = j.Data
+	default:
+		a.data = []byte{}
+	}
+	return nil
+}
+
+// MarshalMessage returns JSON of the Request or Answer as RawMsg
+// with the Session-Id sid.
+// Typed message does not have Session-Id, so its MarshalJSON uses
+// empty Session-Id and MarshalMessage is used to keep it.
+func MarshalMessage(m interface{ ToRaw(string) RawMsg }, sid string) ([]byte, error) {
+	return json.Marshal(m.ToRaw(sid))
+}
+
+// UnmarshalRequest decodes JSON of RawMsg to the request that has same type of r,
+// and returns Session-Id of the request.
+func UnmarshalRequest(b []byte, r Request) (Request, string, error) {
+	var m RawMsg
+	if e := json.Unmarshal(b, &m); e != nil {
+		return nil, "", e
+	}
+	return r.FromRaw(m)
+}
+
+// UnmarshalAnswer decodes JSON of RawMsg to the answer that has same type of a,
+// and returns Session-Id of the answer.
+func UnmarshalAnswer(b []byte, a Answer) (Answer, string, error) {
+	var m RawMsg
+	if e := json.Unmarshal(b, &m); e != nil {
+		return nil, "", e
+	}
+	return a.FromRaw(m)
+}
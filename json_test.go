@@ -0,0 +1,132 @@
+package diameter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	m := RawMsg{Ver: 1, FlgR: true, FlgP: true, Code: 316, AppID: 16777251,
+		HbHID: 0x12345678, EtEID: 0x9abcdef0, AVP: []RawAVP{
+			SetSessionID("mme.example.com;1;2"),
+			SetVendorSpecAppID(10415, 16777251),
+			SetOriginHost("mme.example.com"),
+			SetOriginRealm("example.com"),
+			testPathAVP(t, "Subscription-Data", []RawAVP{
+				testPathAVP(t, "Subscriber-Status", Enumerated(0)),
+				testPathAVP(t, "Max-Requested-Bandwidth-UL", uint32(1000))}),
+			// unknown AVP
+			{Code: 99999, VenID: 1, FlgV: true, data: []byte{1, 2, 3}},
+			// invalid value for the data type
+			{Code: 268, FlgM: true, data: []byte{0, 0, 7}},
+			{Code: 1, FlgM: true, data: []byte{0x61, 0xff}},
+			// empty value
+			{Code: 1, FlgM: true, data: []byte{}},
+		}}
+
+	b, e := json.Marshal(m)
+	if e != nil {
+		t.Fatalf("marshal failed: %s", e)
+	}
+	s := string(b)
+	for _, sub := range []string{
+		`"command":"Update-Location-Request"`,
+		`"flags":"RP"`,
+		`"name":"Origin-Host","flags":"M","value":"mme.example.com"`,
+		`"value":1000`,
+		`"code":99999,"flags":"V","vendor":1,"data":"AQID"`,
+		`"code":268,"name":"Result-Code","flags":"M","data":"AAAH"`,
+	} {
+		if !strings.Contains(s, sub) {
+			t.Errorf("JSON does not contain %s\n%s", sub, s)
+		}
+	}
+
+	var m2 RawMsg
+	if e = json.Unmarshal(b, &m2); e != nil {
+		t.Fatalf("unmarshal failed: %s", e)
+	}
+	w1, w2 := new(bytes.Buffer), new(bytes.Buffer)
+	m.WriteTo(w1)
+	m2.WriteTo(w2)
+	if !bytes.Equal(w1.Bytes(), w2.Bytes()) {
+		t.Errorf("round trip changed message\n% x\n% x", w1.Bytes(), w2.Bytes())
+	}
+}
+
+func TestJSONAVPByName(t *testing.T) {
+	var a RawAVP
+	if e := json.Unmarshal([]byte(
+		`{"name":"Subscription-Data","flags":"VM","avp":[{"name":"Max-Requested-Bandwidth-UL","flags":"VM","value":1000}]}`),
+		&a); e != nil {
+		t.Fatalf("unmarshal failed: %s", e)
+	}
+	if d, _ := LookupAVPByName("Subscription-Data"); a.Code != d.Code || a.VenID != d.VenID {
+		t.Errorf("decoded AVP %d:%d, want %d:%d", a.Code, a.VenID, d.Code, d.VenID)
+	}
+	p, _ := ParsePath("Max-Requested-Bandwidth-UL")
+	var g []RawAVP
+	if e := a.Decode(&g); e != nil {
+		t.Fatalf("decode failed: %s", e)
+	}
+	if l := p.Select(g); len(l) != 1 {
+		t.Errorf("selected %d AVPs, want 1", len(l))
+	} else if s, _ := ValueString(l[0]); s != "1000" {
+		t.Errorf("value %q, want \"1000\"", s)
+	}
+
+	for _, j := range []string{
+		`{"name":"Unknown-AVP-Name","flags":"M","value":1}`,
+		`{"code":99999,"flags":"M","value":1}`,
+		`{"code":264,"flags":"X","value":"host"}`,
+	} {
+		if e := json.Unmarshal([]byte(j), &a); e == nil {
+			t.Errorf("decoded invalid JSON %s", j)
+		}
+	}
+}
+
+func TestJSONMessage(t *testing.T) {
+	req := GenericReq{Code: 316, VenID: 10415, AppID: 16777251,
+		OriginHost: "mme.example.com", OriginRealm: "example.com",
+		DestinationRealm: "example.net",
+		AVP:              []RawAVP{{Code: 99999, VenID: 1, FlgV: true, data: []byte{1}}}}
+	sid := "mme.example.com;1;2"
+
+	b, e := MarshalMessage(req, sid)
+	if e != nil {
+		t.Fatalf("marshal failed: %s", e)
+	}
+	r, s, e := UnmarshalRequest(b, GenericReq{})
+	if e != nil {
+		t.Fatalf("unmarshal failed: %s", e)
+	}
+	if s != sid {
+		t.Errorf("Session-Id %q, want %q", s, sid)
+	}
+	w1, w2 := new(bytes.Buffer), new(bytes.Buffer)
+	req.ToRaw(sid).WriteTo(w1)
+	r.ToRaw(s).WriteTo(w2)
+	if !bytes.Equal(w1.Bytes(), w2.Bytes()) {
+		t.Errorf("round trip changed message\n% x\n% x", w1.Bytes(), w2.Bytes())
+	}
+
+	ans := req.Failed(DiameterUnableToComply)
+	if b, e = MarshalMessage(ans, sid); e != nil {
+		t.Fatalf("marshal failed: %s", e)
+	}
+	a, s, e := UnmarshalAnswer(b, GenericAns{})
+	if e != nil {
+		t.Fatalf("unmarshal failed: %s", e)
+	}
+	if s != sid || a.Result() != DiameterUnableToComply {
+		t.Errorf("decoded %q %d, want %q %d",
+			s, a.Result(), sid, DiameterUnableToComply)
+	}
+
+	if _, _, e = UnmarshalRequest([]byte(`{"flags":"RX"}`), GenericReq{}); e == nil {
+		t.Errorf("decoded invalid command flag")
+	}
+}
@@ -0,0 +1,92 @@
+package diameter
+
+import (
+	"time"
+)
+
+// LatencyBuckets is upper bounds of latency histogram buckets.
+// It is applied to histogram that is created after the change.
+var LatencyBuckets = []time.Duration{
+	time.Millisecond * 5,
+	time.Millisecond * 10,
+	time.Millisecond * 25,
+	time.Millisecond * 50,
+	time.Millisecond * 100,
+	time.Millisecond * 250,
+	time.Millisecond * 500,
+	time.Second,
+	time.Millisecond * 2500,
+	time.Second * 5,
+	time.Second * 10}
+
+// LatencyKey is key of latency histogram
+type LatencyKey struct {
+	AppID uint32
+	Code  uint32
+	// Class is result class (1-5) of answer, or 0 for other result.
+	Class int
+}
+
+// Histogram is latency histogram
+type Histogram struct {
+	// Buckets is upper bounds of buckets
+	Buckets []time.Duration
+	// Counts is number of observation in each bucket (not cumulative).
+	// The last value is count of observation over the last bound.
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	i := 0
+	for ; i < len(h.Buckets); i++ {
+		if d <= h.Buckets[i] {
+			break
+		}
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+// observe record round trip time of the transaction
+func (c *Conn) observe(req, ans RawMsg, d time.Duration) {
+	k := LatencyKey{AppID: req.AppID, Code: req.Code, Class: resultClass(ans)}
+
+	c.statMutex.Lock()
+	if c.latency == nil {
+		c.latency = make(map[LatencyKey]*Histogram)
+	}
+	h, ok := c.latency[k]
+	if !ok {
+		h = &Histogram{
+			Buckets: make([]time.Duration, len(LatencyBuckets)),
+			Counts:  make([]uint64, len(LatencyBuckets)+1)}
+		copy(h.Buckets, LatencyBuckets)
+		c.latency[k] = h
+	}
+	h.observe(d)
+	c.statMutex.Unlock()
+}
+
+// Latency returns answer latency histograms of outbound requests
+// by Application-ID, Command-Code and result class.
+// Relayed request is also recorded.
+// Timed out request is not recorded.
+func (c *Conn) Latency() map[LatencyKey]Histogram {
+	c.statMutex.Lock()
+	defer c.statMutex.Unlock()
+	r := make(map[LatencyKey]Histogram, len(c.latency))
+	for k, h := range c.latency {
+		v := Histogram{
+			Buckets: make([]time.Duration, len(h.Buckets)),
+			Counts:  make([]uint64, len(h.Counts)),
+			Count:   h.Count,
+			Sum:     h.Sum}
+		copy(v.Buckets, h.Buckets)
+		copy(v.Counts, h.Counts)
+		r[k] = v
+	}
+	return r
+}
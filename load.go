@@ -0,0 +1,249 @@
+package diameter
+
+import (
+	"math/rand"
+	"sync"
+)
+
+const (
+	// MaxLoad is Load-Value of fully loaded node
+	MaxLoad uint64 = 65535
+)
+
+var (
+	// LoadReport enables Load AVP (RFC8583) in answer and DWA
+	// that is sent by this node.
+	LoadReport = false
+
+	// LocalLoad returns load value of this node for the peer.
+	LocalLoad = defaultLocalLoad
+
+	loadHost  = make(map[Identity]Load)
+	loadMutex sync.Mutex
+)
+
+// LoadType is Load-Type value
+type LoadType Enumerated
+
+const (
+	// HostLoad is Enumerated value 0
+	HostLoad LoadType = 0
+	// PeerLoad is Enumerated value 1
+	PeerLoad LoadType = 1
+)
+
+func (t LoadType) String() string {
+	switch t {
+	case HostLoad:
+		return "HOST"
+	case PeerLoad:
+		return "PEER"
+	}
+	return "<nil>"
+}
+
+// Load is load information in Load AVP
+type Load struct {
+	Type     LoadType
+	Value    uint64
+	SourceID Identity
+}
+
+// SetLoad make Load AVP
+func SetLoad(v Load) (a RawAVP) {
+	a = RawAVP{Code: 650, VenID: 0, FlgV: false, FlgM: false, FlgP: false}
+	t := []RawAVP{
+		RawAVP{Code: 651, VenID: 0, FlgV: false, FlgM: false, FlgP: false},
+		RawAVP{Code: 652, VenID: 0, FlgV: false, FlgM: false, FlgP: false}}
+	t[0].Encode(Enumerated(v.Type))
+	t[1].Encode(v.Value)
+	if len(v.SourceID) != 0 {
+		s := RawAVP{Code: 649, VenID: 0, FlgV: false, FlgM: false, FlgP: false}
+		s.Encode(v.SourceID)
+		t = append(t, s)
+	}
+	a.Encode(t)
+	return
+}
+
+// GetLoad read Load AVP
+func GetLoad(a RawAVP) (v Load, e error) {
+	o := []RawAVP{}
+	if a.FlgV || a.FlgP {
+		e = InvalidAVP(DiameterInvalidAvpBits)
+	} else {
+		e = a.Decode(&o)
+	}
+	val := false
+	for _, a := range o {
+		if a.VenID != 0 || e != nil {
+			continue
+		}
+		switch a.Code {
+		case 651:
+			var t int32
+			e = a.Decode(&t)
+			v.Type = LoadType(t)
+		case 652:
+			e = a.Decode(&v.Value)
+			val = true
+		case 649:
+			e = a.Decode(&v.SourceID)
+		}
+	}
+	if e != nil {
+	} else if !val {
+		e = InvalidAVP(DiameterMissingAvp)
+	} else if v.Type != HostLoad && v.Type != PeerLoad {
+		e = InvalidAVP(DiameterInvalidAvpValue)
+	} else if v.Value > MaxLoad {
+		e = InvalidAVP(DiameterInvalidAvpValue)
+	}
+	return
+}
+
+// defaultLocalLoad calculate load from depth of Rx and Tx queue
+func defaultLocalLoad(c *Conn) uint64 {
+	rx := cap(c.rcvstack)
+	if RxQueueLimit != 0 && RxQueueLimit < rx {
+		rx = RxQueueLimit
+	}
+	l := uint64(0)
+	if rx > 0 {
+		l = MaxLoad * uint64(c.RxQueue()) / uint64(rx)
+	}
+	if TxBuffer > 0 {
+		if t := MaxLoad * uint64(c.TxQueue()) / uint64(TxBuffer); t > l {
+			l = t
+		}
+	}
+	if l > MaxLoad {
+		l = MaxLoad
+	}
+	return l
+}
+
+// updateLoad store load information that is recieved from the peer
+func updateLoad(c *Conn, l Load) {
+	loadMutex.Lock()
+	switch l.Type {
+	case PeerLoad:
+		c.load = &l
+	case HostLoad:
+		if len(l.SourceID) != 0 {
+			loadHost[l.SourceID] = l
+		}
+	}
+	loadMutex.Unlock()
+}
+
+// handleLoad update load information from the answer
+func handleLoad(c *Conn, m RawMsg) {
+	for _, a := range m.AVP {
+		if a.VenID != 0 || a.Code != 650 {
+			continue
+		}
+		if l, e := GetLoad(a); e == nil {
+			updateLoad(c, l)
+		}
+	}
+}
+
+// setLoadAnswer add Load AVPs to the answer.
+// PEER type Load AVP from other node is replaced with load of this node.
+func setLoadAnswer(c *Conn, ans *RawMsg) {
+	if !LoadReport {
+		return
+	}
+	host := false
+	avp := make([]RawAVP, 0, len(ans.AVP)+2)
+	for _, a := range ans.AVP {
+		if a.VenID == 0 && a.Code == 650 {
+			l, e := GetLoad(a)
+			if e == nil && l.Type == PeerLoad {
+				continue
+			}
+			host = host || (e == nil && l.Type == HostLoad)
+		}
+		avp = append(avp, a)
+	}
+
+	if !host {
+		avp = append(avp, SetLoad(Load{Type: HostLoad, Value: hostLoad(), SourceID: Host}))
+	}
+	ans.AVP = append(avp, SetLoad(Load{Type: PeerLoad, Value: LocalLoad(c), SourceID: Host}))
+}
+
+// hostLoad returns load of this node as highest load for all peers
+func hostLoad() uint64 {
+	connsMutex.Lock()
+	cs := make([]*Conn, 0, len(conns))
+	for c := range conns {
+		cs = append(cs, c)
+	}
+	connsMutex.Unlock()
+
+	l := uint64(0)
+	for _, c := range cs {
+		if v := LocalLoad(c); v > l {
+			l = v
+		}
+	}
+	return l
+}
+
+// PeerLoad returns latest PEER type load that is reported by the peer
+func (c *Conn) PeerLoad() (uint64, bool) {
+	loadMutex.Lock()
+	defer loadMutex.Unlock()
+	if c.load == nil {
+		return 0, false
+	}
+	return c.load.Value, true
+}
+
+// GetHostLoad returns latest HOST type load that is reported by the host
+func GetHostLoad(h Identity) (uint64, bool) {
+	loadMutex.Lock()
+	defer loadMutex.Unlock()
+	l, ok := loadHost[h]
+	return l.Value, ok
+}
+
+// SelectConn choose a Conn from candidates randomly
+// with weight by reported load, for routing or pool of peers.
+// Weight of the Conn is MaxLoad+1 minus higher one of PEER type load
+// of the Conn and HOST type load of dst (or the peer when dst is empty).
+// Conn that is not open or that has open circuit breaker is not selected,
+// and nil is returned when no Conn is available.
+func SelectConn(cs []*Conn, dst Identity) *Conn {
+	var total uint64
+	w := make([]uint64, len(cs))
+	for i, c := range cs {
+		if c == nil || c.getState() != open || c.Breaker() == BreakerOpen {
+			continue
+		}
+		l, _ := c.PeerLoad()
+		h := dst
+		if len(h) == 0 && c.Peer != nil {
+			h = c.Peer.Host
+		}
+		if hl, ok := GetHostLoad(h); ok && hl > l {
+			l = hl
+		}
+		w[i] = MaxLoad + 1 - l
+		total += w[i]
+	}
+	if total == 0 {
+		return nil
+	}
+
+	r := uint64(rand.Int63n(int64(total)))
+	for i, c := range cs {
+		if r < w[i] {
+			return c
+		}
+		r -= w[i]
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package diameter
+
+import "testing"
+
+func testLoadConn(host Identity, s state) *Conn {
+	return &Conn{Peer: &Peer{Host: host}, current: int32(s)}
+}
+
+func TestSelectConn(t *testing.T) {
+	idle := testLoadConn("idle.example.com", open)
+	busy := testLoadConn("busy.example.com", open)
+	updateLoad(busy, Load{Type: PeerLoad, Value: MaxLoad * 3 / 4})
+	full := testLoadConn("full.example.com", open)
+	updateLoad(full, Load{Type: HostLoad, Value: MaxLoad, SourceID: "full.example.com"})
+	closed := testLoadConn("closed.example.com", closing)
+	broken := testLoadConn("broken.example.com", open)
+	broken.brk.state = BreakerOpen
+	defer func() {
+		loadMutex.Lock()
+		delete(loadHost, "full.example.com")
+		delete(loadHost, "dst.example.com")
+		loadMutex.Unlock()
+	}()
+
+	n := map[*Conn]int{}
+	for i := 0; i < 10000; i++ {
+		n[SelectConn([]*Conn{idle, busy, nil, full, closed, broken}, "")]++
+	}
+	if n[closed] != 0 || n[broken] != 0 || n[nil] != 0 {
+		t.Errorf("unavailable Conn is selected: %d closed, %d broken, %d nil",
+			n[closed], n[broken], n[nil])
+	}
+	// weight of idle:busy:full is 4:1:0 approximately
+	if n[idle] < 7500 || n[idle] > 8500 {
+		t.Errorf("idle Conn is selected %d times, want about 8000", n[idle])
+	}
+	if n[busy] < 1500 || n[busy] > 2500 {
+		t.Errorf("busy Conn is selected %d times, want about 2000", n[busy])
+	}
+	if n[full] > 10 {
+		t.Errorf("fully loaded Conn is selected %d times", n[full])
+	}
+
+	// HOST load of the destination is used for all Conns
+	updateLoad(idle, Load{Type: HostLoad, Value: MaxLoad / 2, SourceID: "dst.example.com"})
+	for i := 0; i < 1000; i++ {
+		if c := SelectConn([]*Conn{full}, "dst.example.com"); c != full {
+			t.Fatalf("Conn is not selected by destination load")
+		}
+	}
+
+	if c := SelectConn([]*Conn{closed, broken}, ""); c != nil {
+		t.Errorf("%s is selected from unavailable Conns", c.Peer.Host)
+	}
+	if c := SelectConn(nil, ""); c != nil {
+		t.Errorf("Conn is selected from empty candidates")
+	}
+}
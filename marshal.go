@@ -0,0 +1,337 @@
+package diameter
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type avpField struct {
+	index   int
+	code    uint32
+	venID   uint32
+	flgV    bool
+	flgM    bool
+	flgP    bool
+	notM    bool // M-bit must not be set
+	notP    bool // P-bit must not be set
+	grouped bool
+	multi   bool
+	rest    bool
+}
+
+var (
+	fieldCache sync.Map
+
+	bytesType    = reflect.TypeOf([]byte{})
+	rawAVPsType  = reflect.TypeOf([]RawAVP{})
+	ipType       = reflect.TypeOf(net.IP{})
+	timeType     = reflect.TypeOf(time.Time{})
+	identityType = reflect.TypeOf(Identity(""))
+	uriType      = reflect.TypeOf(URI{})
+	addressType  = reflect.TypeOf(Address{})
+	filterType   = reflect.TypeOf(IPFilterRule{})
+)
+
+// baseType returns type that is supported by Encode and Decode of RawAVP
+func baseType(t reflect.Type) reflect.Type {
+	switch t {
+	case bytesType, rawAVPsType, ipType, timeType, identityType, uriType,
+		addressType, filterType:
+		return t
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.TypeOf("")
+	case reflect.Int32, reflect.Int:
+		return reflect.TypeOf(int32(0))
+	case reflect.Int64:
+		return reflect.TypeOf(int64(0))
+	case reflect.Uint32, reflect.Uint:
+		return reflect.TypeOf(uint32(0))
+	case reflect.Uint64:
+		return reflect.TypeOf(uint64(0))
+	case reflect.Float32:
+		return reflect.TypeOf(float32(0))
+	case reflect.Float64:
+		return reflect.TypeOf(float64(0))
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return bytesType
+		}
+	}
+	return nil
+}
+
+func typeFields(t reflect.Type) ([]avpField, error) {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]avpField), nil
+	}
+
+	fs := make([]avpField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("avp")
+		if !ok || tag == "-" {
+			continue
+		}
+		if sf.PkgPath != "" {
+			return nil, fmt.Errorf("unexported field %s has avp tag", sf.Name)
+		}
+		f := avpField{index: i}
+		opts := strings.Split(tag, ",")
+		if strings.TrimSpace(opts[0]) == "*" {
+			if sf.Type != rawAVPsType {
+				return nil, fmt.Errorf("field %s with tag * must be []RawAVP", sf.Name)
+			}
+			f.rest = true
+			fs = append(fs, f)
+			continue
+		}
+		c, e := strconv.ParseUint(strings.TrimSpace(opts[0]), 10, 32)
+		if e != nil {
+			return nil, fmt.Errorf("invalid AVP code in tag of field %s", sf.Name)
+		}
+		f.code = uint32(c)
+		for _, o := range opts[1:] {
+			switch o = strings.TrimSpace(o); {
+			case o == "V":
+				f.flgV = true
+			case o == "M":
+				f.flgM = true
+			case o == "P":
+				f.flgP = true
+			case o == "!M":
+				f.notM = true
+			case o == "!P":
+				f.notP = true
+			case o == "grouped":
+				f.grouped = true
+			case strings.HasPrefix(o, "vendor="):
+				v, e := strconv.ParseUint(o[7:], 10, 32)
+				if e != nil {
+					return nil, fmt.Errorf("invalid vendor in tag of field %s", sf.Name)
+				}
+				f.venID = uint32(v)
+			default:
+				return nil, fmt.Errorf("unknown option %s in tag of field %s", o, sf.Name)
+			}
+		}
+
+		if (f.flgM && f.notM) || (f.flgP && f.notP) {
+			return nil, fmt.Errorf("conflicting flags in tag of field %s", sf.Name)
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		} else if ft.Kind() == reflect.Slice && (f.grouped || baseType(ft) == nil) {
+			f.multi = true
+			ft = ft.Elem()
+		}
+		if f.grouped && (ft.Kind() != reflect.Struct || ft == timeType || ft == uriType ||
+			ft == addressType || ft == filterType) {
+			return nil, fmt.Errorf("grouped field %s must be struct", sf.Name)
+		}
+		if !f.grouped && baseType(ft) == nil {
+			return nil, fmt.Errorf("unsupported type %s of field %s", ft, sf.Name)
+		}
+		fs = append(fs, f)
+	}
+
+	fieldCache.Store(t, fs)
+	return fs, nil
+}
+
+/*
+Marshal make AVPs from struct value with avp field tag, for example
+
+	type SRR struct {
+		SessionID   string    `avp:"263,M"`
+		OriginHost  Identity  `avp:"264,M"`
+		MSISDN      []byte    `avp:"701,V,M,vendor=10415"`
+		SMSRPSMEA   *[]byte   `avp:"3102,V,M,vendor=10415"`
+		SupportedFeatures []struct {
+			VendorID      uint32 `avp:"266,M"`
+			FeatureListID uint32 `avp:"629,V,vendor=10415"`
+			FeatureList   uint32 `avp:"630,V,vendor=10415"`
+		} `avp:"628,V,vendor=10415,grouped"`
+		AVP []RawAVP `avp:"*"`
+	}
+
+First value of the tag is AVP code, and following options are
+V, M and P for AVP flags, vendor=N for Vendor-ID and grouped for Grouped AVP.
+!M and !P are M and P flag that must not be set.
+Pointer field is optional AVP and nil pointer is not encoded.
+Slice field is AVP that may occur multiple times.
+Struct field with grouped option is Grouped AVP and
+its fields are encoded with the tag recursively.
+Field with tag "*" must be []RawAVP and it has AVPs that are not defined
+in other fields.
+Data type of the field is Identity, URI, Enumerated, Address, IPFilterRule,
+time.Time, net.IP, []byte, []RawAVP or type whose kind is string, int32,
+int64, uint32, uint64, float32 or float64.
+Kind int and uint are encoded as 32bit value.
+*/
+func Marshal(v interface{}) ([]RawAVP, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("nil value")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("invalid type %s, it must be struct", rv.Type())
+	}
+	return marshalStruct(rv)
+}
+
+func marshalStruct(v reflect.Value) ([]RawAVP, error) {
+	fs, e := typeFields(v.Type())
+	if e != nil {
+		return nil, e
+	}
+	avp := []RawAVP{}
+	for _, f := range fs {
+		fv := v.Field(f.index)
+		switch {
+		case f.rest:
+			avp = append(avp, fv.Interface().([]RawAVP)...)
+		case f.multi:
+			for i := 0; i < fv.Len(); i++ {
+				a, e := marshalValue(f, fv.Index(i))
+				if e != nil {
+					return nil, e
+				}
+				avp = append(avp, a)
+			}
+		case fv.Kind() == reflect.Ptr:
+			if fv.IsNil() {
+				continue
+			}
+			a, e := marshalValue(f, fv.Elem())
+			if e != nil {
+				return nil, e
+			}
+			avp = append(avp, a)
+		default:
+			a, e := marshalValue(f, fv)
+			if e != nil {
+				return nil, e
+			}
+			avp = append(avp, a)
+		}
+	}
+	return avp, nil
+}
+
+func marshalValue(f avpField, v reflect.Value) (a RawAVP, e error) {
+	a = RawAVP{Code: f.code, VenID: f.venID,
+		FlgV: f.flgV, FlgM: f.flgM, FlgP: f.flgP}
+	if f.grouped {
+		var sub []RawAVP
+		if sub, e = marshalStruct(v); e == nil {
+			e = a.Encode(sub)
+		}
+	} else {
+		e = a.Encode(v.Convert(baseType(v.Type())).Interface())
+	}
+	return
+}
+
+// Unmarshal set AVPs to struct value with avp field tag.
+// v must be pointer of struct.
+// V flag of the AVP must be same as the tag, M and P flag must be set
+// when the tag has M and P, and must not be set when the tag has !M and !P.
+// Other M and P flag is accepted.
+// It returns InvalidAVP when the AVP has invalid flag or value, and
+// GrammarError when the AVP for non-slice field occurs multiple times.
+// AVP that is not defined in tags is ignored.
+func Unmarshal(avp []RawAVP, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("invalid value, it must be non-nil pointer")
+	}
+	if rv = rv.Elem(); rv.Kind() != reflect.Struct {
+		return fmt.Errorf("invalid type %s, it must be struct", rv.Type())
+	}
+	return unmarshalStruct(avp, rv)
+}
+
+func unmarshalStruct(avp []RawAVP, v reflect.Value) error {
+	fs, e := typeFields(v.Type())
+	if e != nil {
+		return e
+	}
+	rest := -1
+	index := make(map[dictAVPKey]int, len(fs))
+	for i, f := range fs {
+		if f.rest {
+			rest = i
+		} else {
+			index[dictAVPKey{code: f.code, venID: f.venID}] = i
+		}
+	}
+
+	seen := make([]bool, len(fs))
+	for _, a := range avp {
+		i, ok := index[dictAVPKey{code: a.Code, venID: a.VenID}]
+		if !ok {
+			if rest >= 0 {
+				fv := v.Field(fs[rest].index)
+				fv.Set(reflect.Append(fv, reflect.ValueOf(a)))
+			}
+			continue
+		}
+		f := fs[i]
+		if a.FlgV != f.flgV || (f.flgM && !a.FlgM) || (f.notM && a.FlgM) ||
+			(f.flgP && !a.FlgP) || (f.notP && a.FlgP) {
+			return InvalidAVP(DiameterInvalidAvpBits)
+		}
+
+		fv := v.Field(f.index)
+		switch {
+		case f.multi:
+			ev := reflect.New(fv.Type().Elem()).Elem()
+			if e = unmarshalValue(f, a, ev); e != nil {
+				return e
+			}
+			fv.Set(reflect.Append(fv, ev))
+		case seen[i]:
+			return GrammarError{Result: DiameterAvpOccursTooManyTimes, AVP: a}
+		case fv.Kind() == reflect.Ptr:
+			pv := reflect.New(fv.Type().Elem())
+			if e = unmarshalValue(f, a, pv.Elem()); e != nil {
+				return e
+			}
+			fv.Set(pv)
+		default:
+			if e = unmarshalValue(f, a, fv); e != nil {
+				return e
+			}
+		}
+		seen[i] = true
+	}
+	return nil
+}
+
+func unmarshalValue(f avpField, a RawAVP, v reflect.Value) error {
+	if f.grouped {
+		var sub []RawAVP
+		if e := a.Decode(&sub); e != nil {
+			return InvalidAVP(DiameterInvalidAvpValue)
+		}
+		return unmarshalStruct(sub, v)
+	}
+	p := reflect.New(baseType(v.Type()))
+	if e := a.Decode(p.Interface()); e != nil {
+		return InvalidAVP(DiameterInvalidAvpValue)
+	}
+	v.Set(p.Elem().Convert(v.Type()))
+	return nil
+}
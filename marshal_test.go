@@ -0,0 +1,230 @@
+package diameter
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testMarshalFeature struct {
+	VendorID      uint32 `avp:"266,M"`
+	FeatureListID uint32 `avp:"629,V,vendor=10415"`
+	FeatureList   uint32 `avp:"630,V,vendor=10415"`
+}
+
+type testMarshalMsg struct {
+	SessionID   string               `avp:"263,M"`
+	OriginHost  Identity             `avp:"264,M"`
+	ResultCode  *uint32              `avp:"268,M"`
+	StateID     *uint32              `avp:"278,M"`
+	Cause       Enumerated           `avp:"273,M"`
+	Address     net.IP               `avp:"9005,V,M,vendor=10415"`
+	HostAddress []Address            `avp:"257,M"`
+	EventTime   time.Time            `avp:"55,M"`
+	MSISDN      []byte               `avp:"701,V,M,vendor=10415"`
+	RouteRecord []Identity           `avp:"282,M"`
+	Value64     int64                `avp:"9001,!M,!P"`
+	Float       float64              `avp:"9002"`
+	Count       int                  `avp:"9003,P"`
+	Unused      string               `avp:"-"`
+	Features    []testMarshalFeature `avp:"628,V,vendor=10415,grouped"`
+	Extra       *struct {
+		Host Identity `avp:"264,M"`
+	} `avp:"9004,grouped"`
+	AVP []RawAVP `avp:"*"`
+}
+
+func TestMarshal(t *testing.T) {
+	rc := uint32(DiameterSuccess)
+	v := testMarshalMsg{
+		SessionID:   "mme.example.com;1;2",
+		OriginHost:  "mme.example.com",
+		ResultCode:  &rc,
+		Cause:       Rebooting,
+		Address:     net.ParseIP("192.0.2.1").To4(),
+		HostAddress: []Address{IPAddress(net.ParseIP("2001:db8::1")), {Family: AddrE164, Value: []byte("8190")}},
+		EventTime:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		MSISDN:      []byte{0x81, 0x09},
+		RouteRecord: []Identity{"a.example.com", "b.example.com"},
+		Value64:     -1,
+		Float:       0.5,
+		Count:       3,
+		Features:    []testMarshalFeature{{10415, 1, 0x10}, {10415, 2, 0x20}},
+		AVP:         []RawAVP{{Code: 9999, FlgM: true, data: []byte{1, 2}}}}
+	v.Extra = &struct {
+		Host Identity `avp:"264,M"`
+	}{Host: "extra.example.com"}
+
+	avp, e := Marshal(&v)
+	if e != nil {
+		t.Fatalf("marshal failed: %s", e)
+	}
+	// 15 fields include 2 AVPs for each slice, nil pointer is not encoded
+	if len(avp) != 18 {
+		t.Errorf("%d AVPs is encoded, want 18", len(avp))
+	}
+	for _, a := range avp {
+		switch a.Code {
+		case 278:
+			t.Errorf("nil pointer field is encoded")
+		case 701:
+			if !a.FlgV || !a.FlgM || a.FlgP || a.VenID != 10415 {
+				t.Errorf("invalid flag or vendor of MSISDN: %s", a)
+			}
+		case 9003:
+			if a.FlgV || a.FlgM || !a.FlgP {
+				t.Errorf("invalid flag of P-bit AVP: %s", a)
+			}
+		}
+	}
+
+	// AVPs are read from wire format
+	buf := new(bytes.Buffer)
+	for _, a := range avp {
+		a.WriteTo(buf)
+	}
+	avp = avp[:0]
+	for buf.Len() != 0 {
+		var a RawAVP
+		if _, e = a.ReadFrom(buf); e != nil {
+			t.Fatalf("read AVP failed: %s", e)
+		}
+		avp = append(avp, a)
+	}
+
+	var d testMarshalMsg
+	if e = Unmarshal(avp, &d); e != nil {
+		t.Fatalf("unmarshal failed: %s", e)
+	}
+	if !d.EventTime.Equal(v.EventTime) {
+		t.Errorf("time %s, want %s", d.EventTime, v.EventTime)
+	}
+	d.EventTime = v.EventTime
+	if !reflect.DeepEqual(d, v) {
+		t.Errorf("unmarshal result is different\n%+v\n%+v", d, v)
+	}
+}
+
+func TestUnmarshalFlags(t *testing.T) {
+	type msg struct {
+		Host   Identity `avp:"264,M"`
+		MSISDN []byte   `avp:"701,V,M,vendor=10415"`
+		Value  uint32   `avp:"9001,!M,!P"`
+	}
+	tests := []struct {
+		name  string
+		avp   RawAVP
+		valid bool
+	}{
+		{"exact flags", RawAVP{Code: 264, FlgM: true}, true},
+		{"P-bit set by peer", RawAVP{Code: 264, FlgM: true, FlgP: true}, true},
+		{"M-bit is missing", RawAVP{Code: 264}, false},
+		{"V-bit is set", RawAVP{Code: 264, FlgV: true, FlgM: true}, false},
+		{"vendor AVP", RawAVP{Code: 701, VenID: 10415, FlgV: true, FlgM: true}, true},
+		{"V-bit is missing", RawAVP{Code: 701, VenID: 10415, FlgM: true}, false},
+		{"must not flags are clear", RawAVP{Code: 9001}, true},
+		{"must not M-bit", RawAVP{Code: 9001, FlgM: true}, false},
+		{"must not P-bit", RawAVP{Code: 9001, FlgP: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.avp
+			switch a.Code {
+			case 264:
+				a.Encode(Identity("host.example.com"))
+			case 701:
+				a.Encode([]byte{0x81})
+			default:
+				a.Encode(uint32(1))
+			}
+			var m msg
+			e := Unmarshal([]RawAVP{a}, &m)
+			if (e == nil) != tt.valid {
+				t.Fatalf("unmarshal error %v, valid %t", e, tt.valid)
+			}
+			if e != nil && e != InvalidAVP(DiameterInvalidAvpBits) {
+				t.Errorf("error %v, want invalid AVP bits", e)
+			}
+		})
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	type msg struct {
+		Host   Identity   `avp:"264,M"`
+		Routes []Identity `avp:"282,M"`
+		Group  *struct {
+			Value uint32 `avp:"266,M"`
+		} `avp:"260,M,grouped"`
+	}
+	host := SetOriginHost("host.example.com")
+	var m msg
+	if e := Unmarshal([]RawAVP{host, SetRouteRecord("a"), SetRouteRecord("b")}, &m); e != nil {
+		t.Errorf("unmarshal failed: %s", e)
+	} else if len(m.Routes) != 2 || m.Group != nil {
+		t.Errorf("unmarshal result %+v", m)
+	}
+
+	if e := Unmarshal([]RawAVP{host, host}, &m); e == nil {
+		t.Errorf("decoded multiple AVPs for non-slice field")
+	} else if ge, ok := e.(GrammarError); !ok || ge.Result != DiameterAvpOccursTooManyTimes {
+		t.Errorf("error %v, want too many times", e)
+	}
+
+	bad := RawAVP{Code: 260, FlgM: true, data: []byte{0, 0, 1}}
+	if e := Unmarshal([]RawAVP{bad}, &m); e != InvalidAVP(DiameterInvalidAvpValue) {
+		t.Errorf("error %v for invalid Grouped AVP", e)
+	}
+	bad = RawAVP{Code: 264, FlgM: true, data: []byte{}}
+	if e := Unmarshal([]RawAVP{bad}, &m); e != InvalidAVP(DiameterInvalidAvpValue) {
+		t.Errorf("error %v for invalid Identity", e)
+	}
+
+	if e := Unmarshal(nil, m); e == nil {
+		t.Errorf("unmarshal to non-pointer")
+	}
+	if _, e := Marshal(1); e == nil {
+		t.Errorf("marshal non-struct")
+	}
+}
+
+func TestMarshalTag(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"invalid code", struct {
+			A uint32 `avp:"x"`
+		}{}},
+		{"unknown option", struct {
+			A uint32 `avp:"1,X"`
+		}{}},
+		{"invalid vendor", struct {
+			A uint32 `avp:"1,vendor=x"`
+		}{}},
+		{"conflicting flags", struct {
+			A uint32 `avp:"1,M,!M"`
+		}{}},
+		{"rest is not []RawAVP", struct {
+			A []byte `avp:"*"`
+		}{}},
+		{"grouped is not struct", struct {
+			A uint32 `avp:"1,grouped"`
+		}{}},
+		{"unsupported type", struct {
+			A bool `avp:"1"`
+		}{}},
+		{"unexported field", struct {
+			a uint32 `avp:"1"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, e := Marshal(tt.v); e == nil {
+				t.Errorf("marshal with invalid tag")
+			}
+		})
+	}
+}
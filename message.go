@@ -98,6 +98,20 @@ func (m RawMsg) Validate(r, p, e, t bool) error {
 	return nil
 }
 
+// getResult returns Result-Code or Experimental-Result of the answer
+func getResult(m RawMsg) uint32 {
+	for _, a := range m.AVP {
+		if a.VenID != 0 {
+			continue
+		}
+		if a.Code == 268 || a.Code == 297 {
+			r, _ := GetResultCode(a)
+			return r
+		}
+	}
+	return 0
+}
+
 // Clone make copy of this RawMsg
 func (m RawMsg) Clone() RawMsg {
 	avp := make([]RawAVP, len(m.AVP))
@@ -0,0 +1,248 @@
+package diameter
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// CounterKey is key of message counter
+type CounterKey struct {
+	Tx    bool
+	Req   bool
+	AppID uint32
+	Code  uint32
+	// Class is result class (1-5) of answer.
+	// It is 0 for request and answer that has other result.
+	Class int
+}
+
+// resultClass returns result class (1-5) of the answer.
+// Vendor ID of Experimental-Result is ignored.
+func resultClass(m RawMsg) int {
+	if r := getResult(m) % 10000; r >= 1000 && r < 6000 {
+		return int(r / 1000)
+	}
+	return 0
+}
+
+// count update counters of the message
+func (c *Conn) count(tx bool, m RawMsg) {
+	k := CounterKey{Tx: tx, Req: m.FlgR, AppID: m.AppID, Code: m.Code}
+	if m.FlgR {
+		if tx {
+			atomic.AddUint64(&c.TxReq, 1)
+		} else {
+			atomic.AddUint64(&c.RxReq, 1)
+		}
+	} else {
+		k.Class = resultClass(m)
+		var tc, rc *uint64
+		switch k.Class {
+		case 1:
+			tc, rc = &c.Tx1xxx, &c.Rx1xxx
+		case 2:
+			tc, rc = &c.Tx2xxx, &c.Rx2xxx
+		case 3:
+			tc, rc = &c.Tx3xxx, &c.Rx3xxx
+		case 4:
+			tc, rc = &c.Tx4xxx, &c.Rx4xxx
+		case 5:
+			tc, rc = &c.Tx5xxx, &c.Rx5xxx
+		default:
+			tc, rc = &c.TxEtc, &c.RxEtc
+		}
+		if tx {
+			atomic.AddUint64(tc, 1)
+		} else {
+			atomic.AddUint64(rc, 1)
+		}
+	}
+
+	c.statMutex.Lock()
+	if c.stats == nil {
+		c.stats = make(map[CounterKey]uint64)
+	}
+	c.stats[k]++
+	c.statMutex.Unlock()
+}
+
+// Counters returns message counters of the Conn
+// by direction, Application-ID, Command-Code and result class.
+func (c *Conn) Counters() map[CounterKey]uint64 {
+	c.statMutex.Lock()
+	defer c.statMutex.Unlock()
+	r := make(map[CounterKey]uint64, len(c.stats))
+	for k, v := range c.stats {
+		r[k] = v
+	}
+	return r
+}
+
+// MetricsHandler returns http.Handler that exposes counters
+// of all connections in OpenMetrics text format.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type",
+			"application/openmetrics-text; version=1.0.0; charset=utf-8")
+		w.Write(metrics())
+	})
+}
+
+func metricLabel(v string) string {
+	v = strings.Replace(v, `\`, `\\`, -1)
+	v = strings.Replace(v, `"`, `\"`, -1)
+	return strings.Replace(v, "\n", `\n`, -1)
+}
+
+func metrics() []byte {
+	connsMutex.Lock()
+	cs := make([]*Conn, 0, len(conns))
+	for c := range conns {
+		cs = append(cs, c)
+	}
+	connsMutex.Unlock()
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].Peer.String() < cs[j].Peer.String()
+	})
+
+	peer := func(c *Conn) string {
+		if c.Peer == nil {
+			return `peer=""`
+		}
+		return fmt.Sprintf(`peer="%s",realm="%s"`,
+			metricLabel(string(c.Peer.Host)),
+			metricLabel(string(c.Peer.Realm)))
+	}
+	w := new(bytes.Buffer)
+
+	fmt.Fprintln(w, "# TYPE diameter_messages counter")
+	fmt.Fprintln(w, "# HELP diameter_messages Diameter messages.")
+	for _, c := range cs {
+		st := c.Counters()
+		ks := make([]CounterKey, 0, len(st))
+		for k := range st {
+			ks = append(ks, k)
+		}
+		sort.Slice(ks, func(i, j int) bool {
+			a, b := ks[i], ks[j]
+			if a.Tx != b.Tx {
+				return !a.Tx
+			} else if a.Req != b.Req {
+				return a.Req
+			} else if a.AppID != b.AppID {
+				return a.AppID < b.AppID
+			} else if a.Code != b.Code {
+				return a.Code < b.Code
+			}
+			return a.Class < b.Class
+		})
+		for _, k := range ks {
+			dir, typ, class := "rx", "answer", "other"
+			if k.Tx {
+				dir = "tx"
+			}
+			if k.Req {
+				typ, class = "request", ""
+			} else if k.Class != 0 {
+				class = fmt.Sprintf("%dxxx", k.Class)
+			}
+			fmt.Fprintf(w, "diameter_messages_total{%s,direction=\"%s\",type=\"%s\","+
+				"app=\"%d\",cmd=\"%d\",class=\"%s\"} %d\n",
+				peer(c), dir, typ, k.AppID, k.Code, class, st[k])
+		}
+	}
+
+	counters := []struct {
+		name, help string
+		value      func(*Conn) *uint64
+	}{
+		{"diameter_rejected_requests", "Inbound requests rejected in current state.",
+			func(c *Conn) *uint64 { return &c.Reject }},
+		{"diameter_busy_requests", "Inbound requests rejected by admission control.",
+			func(c *Conn) *uint64 { return &c.RxBusy }},
+		{"diameter_failed_requests", "Outbound requests failed to deliver.",
+			func(c *Conn) *uint64 { return &c.TxReqFail }},
+		{"diameter_timeout_requests", "Outbound requests timed out.",
+			func(c *Conn) *uint64 { return &c.TxReqTimeout }},
+		{"diameter_breaker_rejected_requests", "Outbound requests rejected by circuit breaker.",
+			func(c *Conn) *uint64 { return &c.TxReqReject }},
+	}
+	for _, m := range counters {
+		fmt.Fprintf(w, "# TYPE %s counter\n", m.name)
+		fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+		for _, c := range cs {
+			fmt.Fprintf(w, "%s_total{%s} %d\n",
+				m.name, peer(c), atomic.LoadUint64(m.value(c)))
+		}
+	}
+
+	fmt.Fprintln(w, "# TYPE diameter_rx_queue gauge")
+	fmt.Fprintln(w, "# HELP diameter_rx_queue Length of Rx queue.")
+	for _, c := range cs {
+		fmt.Fprintf(w, "diameter_rx_queue{%s} %d\n", peer(c), c.RxQueue())
+	}
+	fmt.Fprintln(w, "# TYPE diameter_tx_queue gauge")
+	fmt.Fprintln(w, "# HELP diameter_tx_queue Length of Tx queue.")
+	for _, c := range cs {
+		fmt.Fprintf(w, "diameter_tx_queue{%s} %d\n", peer(c), c.TxQueue())
+	}
+
+	fmt.Fprintln(w, "# TYPE diameter_request_latency_seconds histogram")
+	fmt.Fprintln(w, "# HELP diameter_request_latency_seconds Answer latency of outbound requests.")
+	for _, c := range cs {
+		lt := c.Latency()
+		ks := make([]LatencyKey, 0, len(lt))
+		for k := range lt {
+			ks = append(ks, k)
+		}
+		sort.Slice(ks, func(i, j int) bool {
+			a, b := ks[i], ks[j]
+			if a.AppID != b.AppID {
+				return a.AppID < b.AppID
+			} else if a.Code != b.Code {
+				return a.Code < b.Code
+			}
+			return a.Class < b.Class
+		})
+		for _, k := range ks {
+			h := lt[k]
+			class := "other"
+			if k.Class != 0 {
+				class = fmt.Sprintf("%dxxx", k.Class)
+			}
+			l := fmt.Sprintf("%s,app=\"%d\",cmd=\"%d\",class=\"%s\"",
+				peer(c), k.AppID, k.Code, class)
+			var n uint64
+			for i, b := range h.Buckets {
+				n += h.Counts[i]
+				fmt.Fprintf(w, "diameter_request_latency_seconds_bucket{%s,le=\"%g\"} %d\n",
+					l, b.Seconds(), n)
+			}
+			fmt.Fprintf(w, "diameter_request_latency_seconds_bucket{%s,le=\"+Inf\"} %d\n",
+				l, h.Count)
+			fmt.Fprintf(w, "diameter_request_latency_seconds_count{%s} %d\n", l, h.Count)
+			fmt.Fprintf(w, "diameter_request_latency_seconds_sum{%s} %g\n", l, h.Sum.Seconds())
+		}
+	}
+
+	fmt.Fprintln(w, "# TYPE diameter_peer_state stateset")
+	fmt.Fprintln(w, "# HELP diameter_peer_state State of peer connection.")
+	for _, c := range cs {
+		now := c.getState()
+		for s := closed; s <= closing; s++ {
+			v := 0
+			if s == now {
+				v = 1
+			}
+			fmt.Fprintf(w, "diameter_peer_state{%s,diameter_peer_state=\"%s\"} %d\n",
+				peer(c), s, v)
+		}
+	}
+
+	fmt.Fprintln(w, "# EOF")
+	return w.Bytes()
+}
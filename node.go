@@ -3,6 +3,7 @@ package diameter
 import (
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -25,6 +26,9 @@ var (
 	// and Supported-Vendor-Id AVP
 	supportedApps = make(map[uint32]appSet)
 
+	peerStateID = make(map[Identity]uint32)
+	peerMutex   sync.Mutex
+
 	hbHID     = make(chan uint32, 1)
 	etEID     = make(chan uint32, 1)
 	sessionID = make(chan uint32, 1)
@@ -63,6 +67,7 @@ func init() {
 	sessionID <- rand.Uint32()
 
 	StateID = uint32(ut)
+	instanceID = randomInstanceID()
 }
 
 // AddSupportedMessage add supported application message
@@ -94,6 +99,7 @@ func nextHbH() uint32 {
 
 func nextEtE() uint32 {
 	ret := <-etEID
+	reserveEtE(ret)
 	etEID <- ret + 1
 	return ret
 }
@@ -101,8 +107,37 @@ func nextEtE() uint32 {
 func nextSession() string {
 	ret := <-sessionID
 	sessionID <- ret + 1
-	return fmt.Sprintf("%s;%d;%d;0",
-		Host, time.Now().Unix()+2208988800, ret)
+	id := InstanceID
+	if len(id) == 0 {
+		id = instanceID
+	}
+	return fmt.Sprintf("%s;%d;%d;%s", Host, StateID, ret, id)
+}
+
+// checkStateID compare Origin-State-Id of the peer with previous value.
+// Records of the peer are purged when the peer is rebooted.
+func checkStateID(c *Conn, h Identity, id uint32) {
+	if id == 0 || len(h) == 0 {
+		return
+	}
+	peerMutex.Lock()
+	old, ok := peerStateID[h]
+	peerStateID[h] = id
+	peerMutex.Unlock()
+	if !ok || old == id {
+		return
+	}
+
+	purgeDuplicate(h)
+	ocMutex.Lock()
+	delete(ocHost, h)
+	ocMutex.Unlock()
+	loadMutex.Lock()
+	delete(loadHost, h)
+	loadMutex.Unlock()
+
+	publish(PeerRebootEvent{Host: h, OldID: old, NewID: id, Conn: c})
+	HandlePeerReboot(h, c)
 }
 
 // Peer is peer node of Diameter
@@ -6,7 +6,9 @@ import (
 	"log"
 )
 
-// Notify is called when error or trace event are occured
+// Notify is called when error or trace event are occured.
+// Notice is not logged when value is nil.
+// Use Subscribe to recieve only required type of Notice.
 var Notify = func(n Notice) {
 	log.Println(n)
 }
@@ -18,20 +20,20 @@ type Notice interface {
 
 // StateUpdate notify event
 type StateUpdate struct {
-	oldStat state
-	newStat state
-	stateEvent
-	conn *Conn
-	Err  error
+	OldState ConnState
+	NewState ConnState
+	Event    string
+	Conn     *Conn
+	Err      error
 }
 
 func (e StateUpdate) String() string {
 	w := new(bytes.Buffer)
-	fmt.Fprintf(w, "Event %s: Peer %s", e.stateEvent, e.conn.Peer)
-	if e.oldStat != e.newStat {
-		fmt.Fprintf(w, ": State %s -> %s", e.oldStat, e.newStat)
+	fmt.Fprintf(w, "Event %s: Peer %s", e.Event, e.Conn.Peer)
+	if e.OldState != e.NewState {
+		fmt.Fprintf(w, ": State %s -> %s", e.OldState, e.NewState)
 	} else {
-		fmt.Fprintf(w, ": State %s", e.oldStat)
+		fmt.Fprintf(w, ": State %s", e.OldState)
 	}
 	if e.Err != nil {
 		fmt.Fprintf(w, ": Failed: %s", e.Err)
@@ -60,48 +62,138 @@ func msgHandleLog(x, r bool, c *Conn, e error, req, ans string) string {
 
 // CapabilityExchangeEvent notify capability exchange related event
 type CapabilityExchangeEvent struct {
-	tx   bool
-	req  bool
-	conn *Conn
+	Tx   bool
+	Req  bool
+	Conn *Conn
+	Msg  RawMsg
 	Err  error
 }
 
 func (e CapabilityExchangeEvent) String() string {
-	return msgHandleLog(e.tx, e.req, e.conn, e.Err, "CER", "CEA")
+	return msgHandleLog(e.Tx, e.Req, e.Conn, e.Err, "CER", "CEA")
 }
 
 // WatchdogEvent notify watchdog related event
 type WatchdogEvent struct {
-	tx   bool
-	req  bool
-	conn *Conn
+	Tx   bool
+	Req  bool
+	Conn *Conn
+	Msg  RawMsg
 	Err  error
 }
 
 func (e WatchdogEvent) String() string {
-	return msgHandleLog(e.tx, e.req, e.conn, e.Err, "DWR", "DWA")
+	return msgHandleLog(e.Tx, e.Req, e.Conn, e.Err, "DWR", "DWA")
 }
 
 // MessageEvent notify diameter message related event
 type MessageEvent struct {
-	tx   bool
-	req  bool
-	conn *Conn
+	Tx   bool
+	Req  bool
+	Conn *Conn
+	Msg  RawMsg
 	Err  error
 }
 
 func (e MessageEvent) String() string {
-	return msgHandleLog(e.tx, e.req, e.conn, e.Err, "REQ", "ANS")
+	return msgHandleLog(e.Tx, e.Req, e.Conn, e.Err, "REQ", "ANS")
 }
 
 // PurgeEvent notify diameter purge related event
 type PurgeEvent struct {
-	tx   bool
-	req  bool
-	conn *Conn
+	Tx   bool
+	Req  bool
+	Conn *Conn
+	Msg  RawMsg
 	Err  error
 }
 
 func (e PurgeEvent) String() string {
-	return msgHandleLog(e.tx, e.req, e.conn, e.Err, "DPR", "DPA")
+	return msgHandleLog(e.Tx, e.Req, e.Conn, e.Err, "DPR", "DPA")
+}
+
+// DuplicateEvent notify duplicated request related event
+type DuplicateEvent struct {
+	Replay bool
+	Conn   *Conn
+	Msg    RawMsg
+	Err    error
+}
+
+func (e DuplicateEvent) String() string {
+	w := new(bytes.Buffer)
+	fmt.Fprintf(w, "<- duplicated REQ (%s)", e.Conn.Peer)
+	if e.Replay {
+		fmt.Fprintf(w, ": answer is replayed")
+	} else {
+		fmt.Fprintf(w, ": answer will be sent when original request is answered")
+	}
+	if e.Err != nil {
+		fmt.Fprintf(w, ": Failed: %s", e.Err)
+	}
+	return w.String()
+}
+
+// AdmissionEvent notify inbound admission control related event
+type AdmissionEvent struct {
+	Shed bool
+	Conn *Conn
+}
+
+func (e AdmissionEvent) String() string {
+	if e.Shed {
+		return fmt.Sprintf("Admission (%s): start shedding request", e.Conn.Peer)
+	}
+	return fmt.Sprintf("Admission (%s): stop shedding request", e.Conn.Peer)
+}
+
+// BreakerEvent notify outbound circuit breaker related event
+type BreakerEvent struct {
+	OldState BreakerState
+	NewState BreakerState
+	Conn     *Conn
+}
+
+func (e BreakerEvent) String() string {
+	return fmt.Sprintf("Circuit breaker (%s): State %s -> %s",
+		e.Conn.Peer, e.OldState, e.NewState)
+}
+
+// OverloadEvent notify overload report is recieved
+type OverloadEvent struct {
+	OLR
+	Node Identity
+	Conn *Conn
+}
+
+func (e OverloadEvent) String() string {
+	if e.ValidityDuration == 0 {
+		return fmt.Sprintf("<- OLR (%s): %s of %s is cancelled",
+			e.Conn.Peer, e.ReportType, e.Node)
+	}
+	return fmt.Sprintf("<- OLR (%s): %s of %s, reduction=%d%%, validity=%s",
+		e.Conn.Peer, e.ReportType, e.Node,
+		e.ReductionPercentage, e.ValidityDuration)
+}
+
+// PeerRebootEvent notify reboot of the peer is detected
+type PeerRebootEvent struct {
+	Host  Identity
+	OldID uint32
+	NewID uint32
+	Conn  *Conn
+}
+
+func (e PeerRebootEvent) String() string {
+	return fmt.Sprintf("Peer %s (%s) is rebooted: Origin-State-Id %d -> %d",
+		e.Host, e.Conn.Peer, e.OldID, e.NewID)
+}
+
+// StateFileEvent notify state file related event
+type StateFileEvent struct {
+	Err error
+}
+
+func (e StateFileEvent) String() string {
+	return fmt.Sprintf("State file %s: Failed: %s", stateFile, e.Err)
 }
@@ -0,0 +1,125 @@
+/*
+Package otel provides adapter of OpenTelemetry tracer for diameter.Tracer.
+
+	diameter.Tracer = otel.New(otel.GetTracerProvider().Tracer("diameter"))
+	diameter.TraceVendorID = vendorID // Vendor-ID that is agreed with the peers
+	diameter.TracePropagation = true
+
+This package is built with go.opentelemetry.io/otel v1.24.0,
+and the importing module should require that version or later.
+*/
+package otel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	dia "github.com/fkgi/diameter"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer is adapter of OpenTelemetry tracer
+type Tracer struct {
+	trace.Tracer
+	// Propagator encodes trace context in Trace-Context AVP.
+	// W3C trace context is used when value is nil.
+	Propagator propagation.TextMapPropagator
+}
+
+// New returns new adapter of the tracer
+func New(t trace.Tracer) *Tracer {
+	return &Tracer{Tracer: t}
+}
+
+func (t *Tracer) propagator() propagation.TextMapPropagator {
+	if t.Propagator == nil {
+		return propagation.TraceContext{}
+	}
+	return t.Propagator
+}
+
+// Start start span of Diameter transaction
+func (t *Tracer) Start(name string, kind dia.SpanKind, parent []byte,
+	attrs map[string]interface{}) dia.Span {
+	ctx := context.Background()
+	if len(parent) != 0 {
+		ctx = t.propagator().Extract(ctx, decode(parent))
+	}
+
+	k := trace.SpanKindClient
+	if kind == dia.SpanServer {
+		k = trace.SpanKindServer
+	}
+	as := toAttributes(attrs)
+	as = append(as, attribute.String("diameter.span", kind.String()))
+
+	ctx, s := t.Tracer.Start(ctx, name,
+		trace.WithSpanKind(k), trace.WithAttributes(as...))
+	return &span{ctx: ctx, span: s, prop: t.propagator()}
+}
+
+type span struct {
+	ctx  context.Context
+	span trace.Span
+	prop propagation.TextMapPropagator
+}
+
+func (s *span) Context() []byte {
+	c := propagation.MapCarrier{}
+	s.prop.Inject(s.ctx, c)
+	return encode(c)
+}
+
+func (s *span) End(attrs map[string]interface{}, err error) {
+	s.span.SetAttributes(toAttributes(attrs)...)
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	} else if r, ok := attrs["diameter.result"].(uint32); ok && r%10000 >= 3000 {
+		s.span.SetStatus(codes.Error, fmt.Sprintf("result=%d", r))
+	}
+	s.span.End()
+}
+
+func toAttributes(attrs map[string]interface{}) []attribute.KeyValue {
+	r := make([]attribute.KeyValue, 0, len(attrs)+1)
+	for k, v := range attrs {
+		switch v := v.(type) {
+		case string:
+			r = append(r, attribute.String(k, v))
+		case uint32:
+			r = append(r, attribute.Int64(k, int64(v)))
+		case int:
+			r = append(r, attribute.Int(k, v))
+		case bool:
+			r = append(r, attribute.Bool(k, v))
+		default:
+			r = append(r, attribute.String(k, fmt.Sprint(v)))
+		}
+	}
+	return r
+}
+
+// encode carrier as "key:value" lines
+func encode(c propagation.MapCarrier) []byte {
+	w := new(bytes.Buffer)
+	for _, k := range c.Keys() {
+		fmt.Fprintf(w, "%s:%s\n", k, c.Get(k))
+	}
+	return w.Bytes()
+}
+
+func decode(b []byte) propagation.MapCarrier {
+	c := propagation.MapCarrier{}
+	for _, l := range strings.Split(string(b), "\n") {
+		if i := strings.Index(l, ":"); i > 0 {
+			c.Set(l[:i], l[i+1:])
+		}
+	}
+	return c
+}
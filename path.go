@@ -0,0 +1,407 @@
+package diameter
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Path is query of AVP in message or Grouped AVP.
+Path is list of step that is separated by "/",
+and each step selects AVPs in the Grouped AVP that is selected by previous step.
+
+	Subscription-Data/APN-Configuration-Profile/APN-Configuration[2]/Service-Selection
+	Vendor-Specific-Application-Id/Auth-Application-Id
+	Subscription-Data/APN-Configuration-Profile/APN-Configuration[Context-Identifier=1]/Service-Selection
+	1400:10415/*
+
+Step is AVP name in dictionary, AVP code and vendor ID as "code:vendor",
+or "*" that matches any AVP. Step may have predicates in brackets.
+
+	[n]            n-th AVP of the selected AVPs (starts from 1)
+	[Name]         AVP that has the child AVP
+	[Name=value]   AVP that has the child AVP of the value
+
+Value of predicate is text of ValueString, and it can be quoted with '"'
+when it contains "/" or "]". Enumerated value can also be specified by name.
+*/
+type Path struct {
+	text  string
+	steps []pathStep
+}
+
+type pathStep struct {
+	any   bool
+	avp   DictAVP
+	preds []pathPred
+}
+
+type pathPred struct {
+	index int
+	child DictAVP
+	value []byte
+}
+
+// ParsePath parse text of Path
+func ParsePath(s string) (p Path, e error) {
+	p.text = s
+	if len(s) == 0 {
+		return p, fmt.Errorf("empty path")
+	}
+	for _, t := range splitPath(s, '/') {
+		var st pathStep
+		name := t
+		if i := strings.IndexByte(t, '['); i >= 0 {
+			name = t[:i]
+			for t = t[i:]; len(t) != 0; {
+				if t[0] != '[' {
+					return p, fmt.Errorf("invalid predicate %s", t)
+				}
+				j := closeBracket(t)
+				if j < 0 {
+					return p, fmt.Errorf("unclosed predicate %s", t)
+				}
+				var pr pathPred
+				if pr, e = parsePathPred(t[1:j]); e != nil {
+					return
+				}
+				st.preds = append(st.preds, pr)
+				t = t[j+1:]
+			}
+		}
+		if name == "*" {
+			st.any = true
+		} else if st.avp, e = lookupPathName(name); e != nil {
+			return
+		}
+		p.steps = append(p.steps, st)
+	}
+	return
+}
+
+// splitPath split s by sep that is not in brackets or quotes
+func splitPath(s string, sep byte) []string {
+	var r []string
+	depth, quote, start := 0, false, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			quote = !quote
+		case quote:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == sep && depth == 0:
+			r = append(r, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(r, s[start:])
+}
+
+func closeBracket(s string) int {
+	quote := false
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quote = !quote
+		case ']':
+			if !quote {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parsePathPred(s string) (p pathPred, e error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 {
+			return p, fmt.Errorf("invalid index %s", s)
+		}
+		p.index = n
+		return
+	}
+	name, v := s, ""
+	i := strings.IndexByte(s, '=')
+	if i >= 0 {
+		name, v = s[:i], s[i+1:]
+	}
+	if p.child, e = lookupPathName(name); e != nil || i < 0 {
+		return
+	}
+	if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+		v = v[1 : len(v)-1]
+	}
+	a := RawAVP{}
+	if e = a.setValueString(v, p.child); e != nil {
+		return p, fmt.Errorf("invalid value %s of %s: %v", v, name, e)
+	}
+	p.value = a.data
+	return
+}
+
+func lookupPathName(s string) (DictAVP, error) {
+	if d, ok := LookupAVPByName(s); ok {
+		return d, nil
+	}
+	c, v := s, "0"
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		c, v = s[:i], s[i+1:]
+	}
+	code, e1 := strconv.ParseUint(c, 10, 32)
+	ven, e2 := strconv.ParseUint(v, 10, 32)
+	if e1 != nil || e2 != nil {
+		return DictAVP{}, fmt.Errorf("unknown AVP %s", s)
+	}
+	if d, ok := LookupAVP(uint32(code), uint32(ven)); ok {
+		return d, nil
+	}
+	return DictAVP{Code: uint32(code), VenID: uint32(ven), Type: "OctetString"}, nil
+}
+
+func (p Path) String() string {
+	return p.text
+}
+
+func (s pathStep) match(a RawAVP) bool {
+	return s.any || (a.Code == s.avp.Code && a.VenID == s.avp.VenID)
+}
+
+// filter returns index of AVPs that match with the step
+func (s pathStep) filter(avp []RawAVP) []int {
+	var r []int
+	for i, a := range avp {
+		if s.match(a) {
+			r = append(r, i)
+		}
+	}
+	for _, pr := range s.preds {
+		if pr.index != 0 {
+			if pr.index > len(r) {
+				return nil
+			}
+			r = r[pr.index-1 : pr.index]
+			continue
+		}
+		f := r[:0:0]
+		for _, i := range r {
+			var g GroupedAVP
+			if avp[i].Decode(&g) != nil {
+				continue
+			}
+			for _, c := range g.GetAll(pr.child.Code, pr.child.VenID) {
+				if pr.value == nil || bytes.Equal(c.data, pr.value) {
+					f = append(f, i)
+					break
+				}
+			}
+		}
+		r = f
+	}
+	return r
+}
+
+// Select returns all AVPs that match with the path
+func (p Path) Select(avp []RawAVP) []RawAVP {
+	var r []RawAVP
+	p.edit(avp, 0, func(a RawAVP) (RawAVP, bool) {
+		r = append(r, a)
+		return a, true
+	})
+	return r
+}
+
+// Update replaces all AVPs that match with the path by result of f,
+// and the AVP is deleted when f returns false.
+// Grouped AVPs that contain the updated AVP are encoded again.
+// It returns new AVP list and number of matched AVPs.
+// Original AVP list is not modified.
+func (p Path) Update(avp []RawAVP, f func(RawAVP) (RawAVP, bool)) ([]RawAVP, int) {
+	return p.edit(avp, 0, f)
+}
+
+// Delete deletes all AVPs that match with the path,
+// and returns new AVP list and number of deleted AVPs.
+func (p Path) Delete(avp []RawAVP) ([]RawAVP, int) {
+	return p.edit(avp, 0, func(a RawAVP) (RawAVP, bool) {
+		return a, false
+	})
+}
+
+func (p Path) edit(avp []RawAVP, i int, f func(RawAVP) (RawAVP, bool)) ([]RawAVP, int) {
+	idx := p.steps[i].filter(avp)
+	if len(idx) == 0 {
+		return avp, 0
+	}
+	r := make([]RawAVP, len(avp))
+	copy(r, avp)
+	del := make(map[int]bool)
+	n := 0
+	for _, j := range idx {
+		if i == len(p.steps)-1 {
+			a, keep := f(r[j])
+			if keep {
+				r[j] = a
+			} else {
+				del[j] = true
+			}
+			n++
+			continue
+		}
+
+		var g GroupedAVP
+		if r[j].Decode(&g) != nil {
+			continue
+		}
+		g, c := p.edit(g, i+1, f)
+		if c == 0 {
+			continue
+		}
+		r[j].Encode(g)
+		n += c
+	}
+	if len(del) != 0 {
+		t := r[:0]
+		for j, a := range r {
+			if !del[j] {
+				t = append(t, a)
+			}
+		}
+		r = t
+	}
+	return r, n
+}
+
+// create adds AVP at the path with Grouped AVPs that are not exist.
+// All steps must be AVP name or code without predicate.
+func (p Path) create(avp []RawAVP, i int, a RawAVP) ([]RawAVP, error) {
+	s := p.steps[i]
+	if s.any || len(s.preds) != 0 {
+		return avp, fmt.Errorf("path %s can't create AVP", p.text)
+	}
+	if i == len(p.steps)-1 {
+		return append(avp, a), nil
+	}
+
+	r := make([]RawAVP, len(avp), len(avp)+1)
+	copy(r, avp)
+	j := -1
+	for k, o := range r {
+		if s.match(o) {
+			j = k
+			break
+		}
+	}
+	if j < 0 {
+		r = append(r, RawAVP{Code: s.avp.Code, VenID: s.avp.VenID,
+			FlgV: s.avp.VenID != 0, FlgM: s.avp.Mandatory == FlagMust,
+			FlgP: s.avp.Protected == FlagMust, data: []byte{}})
+		j = len(r) - 1
+	}
+	var g GroupedAVP
+	if e := r[j].Decode(&g); e != nil {
+		return avp, e
+	}
+	g, e := p.create(g, i+1, a)
+	if e != nil {
+		return avp, e
+	}
+	return r, r[j].Encode(g)
+}
+
+// Select returns all AVPs in the message that match with the path
+func (m RawMsg) Select(path string) ([]RawAVP, error) {
+	p, e := ParsePath(path)
+	if e != nil {
+		return nil, e
+	}
+	return p.Select(m.AVP), nil
+}
+
+// GetValue decodes value of first AVP that match with the path to v.
+// It returns error when no AVP matches.
+func (m RawMsg) GetValue(path string, v interface{}) error {
+	l, e := m.Select(path)
+	if e != nil {
+		return e
+	}
+	if len(l) == 0 {
+		return fmt.Errorf("no AVP for %s", path)
+	}
+	return l[0].Decode(v)
+}
+
+// GetString returns ValueString of first AVP that match with the path
+func (m RawMsg) GetString(path string) (string, error) {
+	l, e := m.Select(path)
+	if e != nil {
+		return "", e
+	}
+	if len(l) == 0 {
+		return "", fmt.Errorf("no AVP for %s", path)
+	}
+	return ValueString(l[0])
+}
+
+// SetValue encodes v to all AVPs that match with the path.
+// When no AVP matches, new AVP and Grouped AVPs that contain it
+// are added with flags of dictionary.
+func (m *RawMsg) SetValue(path string, v interface{}) error {
+	p, e := ParsePath(path)
+	if e != nil {
+		return e
+	}
+	return m.update(p, func(a *RawAVP) error { return a.Encode(v) })
+}
+
+// SetString set value text of ValueString to all AVPs that match with
+// the path as SetValue.
+func (m *RawMsg) SetString(path, s string) error {
+	p, e := ParsePath(path)
+	if e != nil {
+		return e
+	}
+	return m.update(p, func(a *RawAVP) error { return a.SetValueString(s) })
+}
+
+func (m *RawMsg) update(p Path, f func(*RawAVP) error) (e error) {
+	avp, n := p.Update(m.AVP, func(a RawAVP) (RawAVP, bool) {
+		if e == nil {
+			e = f(&a)
+		}
+		return a, true
+	})
+	if e != nil {
+		return
+	}
+	if n == 0 {
+		d := p.steps[len(p.steps)-1].avp
+		a := RawAVP{Code: d.Code, VenID: d.VenID,
+			FlgV: d.VenID != 0, FlgM: d.Mandatory == FlagMust,
+			FlgP: d.Protected == FlagMust}
+		if e = f(&a); e != nil {
+			return
+		}
+		if avp, e = p.create(m.AVP, 0, a); e != nil {
+			return
+		}
+	}
+	m.AVP = avp
+	return
+}
+
+// DeletePath deletes all AVPs in the message that match with the path,
+// and returns number of deleted AVPs.
+func (m *RawMsg) DeletePath(path string) (int, error) {
+	p, e := ParsePath(path)
+	if e != nil {
+		return 0, e
+	}
+	avp, n := p.Delete(m.AVP)
+	m.AVP = avp
+	return n, nil
+}
@@ -0,0 +1,122 @@
+package diameter
+
+import (
+	"testing"
+)
+
+func testPathAVP(t *testing.T, name string, v interface{}) RawAVP {
+	d, ok := LookupAVPByName(name)
+	if !ok {
+		t.Fatalf("unknown AVP %s", name)
+	}
+	a := RawAVP{Code: d.Code, VenID: d.VenID, FlgV: d.VenID != 0,
+		FlgM: d.Mandatory == FlagMust}
+	if e := a.Encode(v); e != nil {
+		t.Fatalf("encode %s failed: %s", name, e)
+	}
+	return a
+}
+
+func testPathMsg(t *testing.T) RawMsg {
+	apn := func(id uint32, name string) RawAVP {
+		return testPathAVP(t, "APN-Configuration", []RawAVP{
+			testPathAVP(t, "Context-Identifier", id),
+			testPathAVP(t, "PDN-Type", Enumerated(0)),
+			testPathAVP(t, "Service-Selection", name)})
+	}
+	return RawMsg{Ver: 1, Code: 316, AppID: 16777251, AVP: []RawAVP{
+		SetOriginHost("hss.example.com"),
+		SetVendorSpecAppID(10415, 16777251),
+		testPathAVP(t, "Subscription-Data", []RawAVP{
+			testPathAVP(t, "MSISDN", []byte{0x81, 0x09}),
+			testPathAVP(t, "APN-Configuration-Profile", []RawAVP{
+				testPathAVP(t, "Context-Identifier", uint32(1)),
+				testPathAVP(t, "All-APN-Configurations-Included-Indicator", Enumerated(0)),
+				apn(1, "internet"),
+				apn(2, "ims")})})}}
+}
+
+func TestPathExamples(t *testing.T) {
+	m := testPathMsg(t)
+	tests := []struct {
+		path string
+		n    int
+		str  string
+	}{
+		{"Subscription-Data/APN-Configuration-Profile/APN-Configuration[2]/Service-Selection", 1, "ims"},
+		{"Vendor-Specific-Application-Id/Auth-Application-Id", 1, "16777251"},
+		{"Subscription-Data/APN-Configuration-Profile/APN-Configuration[Context-Identifier=1]/Service-Selection", 1, "internet"},
+		{"Subscription-Data/APN-Configuration-Profile/APN-Configuration[PDN-Type=IPv4]/Service-Selection", 2, "internet"},
+		{"1400:10415/*", 2, "8109"},
+		{"Subscription-Data/APN-Configuration-Profile/APN-Configuration[3]", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			l, e := m.Select(tt.path)
+			if e != nil {
+				t.Fatalf("select failed: %s", e)
+			}
+			if len(l) != tt.n {
+				t.Fatalf("selected %d AVPs, want %d", len(l), tt.n)
+			}
+			if tt.n == 0 {
+				return
+			}
+			if s, e := m.GetString(tt.path); e != nil {
+				t.Errorf("get failed: %s", e)
+			} else if s != tt.str {
+				t.Errorf("value %q, want %q", s, tt.str)
+			}
+		})
+	}
+
+	// relative path from Grouped AVP
+	prof, _ := m.Select("Subscription-Data/APN-Configuration-Profile")
+	if len(prof) != 1 {
+		t.Fatalf("no APN-Configuration-Profile")
+	}
+	var g []RawAVP
+	prof[0].Decode(&g)
+	p, e := ParsePath("APN-Configuration[Context-Identifier=2]/Service-Selection")
+	if e != nil {
+		t.Fatalf("parse failed: %s", e)
+	}
+	if l := p.Select(g); len(l) != 1 {
+		t.Errorf("selected %d AVPs from Grouped AVP, want 1", len(l))
+	} else if s, _ := ValueString(l[0]); s != "ims" {
+		t.Errorf("value %q, want \"ims\"", s)
+	}
+}
+
+func TestPathUpdate(t *testing.T) {
+	m := testPathMsg(t)
+	path := "Subscription-Data/APN-Configuration-Profile/APN-Configuration[Context-Identifier=2]/Service-Selection"
+	if e := m.SetString(path, "mms"); e != nil {
+		t.Fatalf("set failed: %s", e)
+	}
+	if s, _ := m.GetString(path); s != "mms" {
+		t.Errorf("value %q, want \"mms\"", s)
+	}
+	if s, _ := m.GetString("Subscription-Data/APN-Configuration-Profile/APN-Configuration[1]/Service-Selection"); s != "internet" {
+		t.Errorf("other APN is changed to %q", s)
+	}
+
+	if e := m.SetString("Subscription-Data/AMBR/Max-Requested-Bandwidth-UL", "1000"); e != nil {
+		t.Fatalf("set of new AVP failed: %s", e)
+	}
+	var v uint32
+	if e := m.GetValue("Subscription-Data/AMBR/Max-Requested-Bandwidth-UL", &v); e != nil || v != 1000 {
+		t.Errorf("value %d (%v), want 1000", v, e)
+	}
+
+	if n, e := m.DeletePath("Subscription-Data/APN-Configuration-Profile/APN-Configuration[Service-Selection=internet]"); e != nil || n != 1 {
+		t.Errorf("deleted %d AVPs (%v), want 1", n, e)
+	}
+	if l, _ := m.Select("Subscription-Data/APN-Configuration-Profile/APN-Configuration"); len(l) != 1 {
+		t.Errorf("%d APN-Configuration remains, want 1", len(l))
+	}
+
+	if _, e := ParsePath("Subscription-Data/Unknown-AVP-Name"); e == nil {
+		t.Errorf("parsed path of unknown AVP")
+	}
+}
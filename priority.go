@@ -0,0 +1,88 @@
+package diameter
+
+import (
+	"container/heap"
+	"sync"
+)
+
+var (
+	// DefaultPriority is priority of message without DRMP AVP
+	DefaultPriority = Priority10
+	// ShedPriority is lowest priority of request that is admitted
+	// while admission control is shedding load.
+	// All request is shed when value is UnknownPriority.
+	ShedPriority = Priority9
+)
+
+// getPriority returns DRMP value of the message
+func getPriority(m RawMsg) DRMP {
+	for _, a := range m.AVP {
+		if a.Code == 301 && a.VenID == 0 {
+			if v, e := GetDRMP(a); e == nil {
+				return v
+			}
+			break
+		}
+	}
+	return DefaultPriority
+}
+
+type sndItem struct {
+	m   RawMsg
+	p   DRMP
+	seq uint64
+}
+
+// sndQueue is priority queue of sending message.
+// Message with same priority is sent in FIFO order.
+type sndQueue struct {
+	sync.Mutex
+	items []sndItem
+	seq   uint64
+}
+
+func (q *sndQueue) Len() int { return len(q.items) }
+
+func (q *sndQueue) Less(i, j int) bool {
+	if q.items[i].p != q.items[j].p {
+		return q.items[i].p < q.items[j].p
+	}
+	return q.items[i].seq < q.items[j].seq
+}
+
+func (q *sndQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+}
+
+func (q *sndQueue) Push(x interface{}) {
+	q.items = append(q.items, x.(sndItem))
+}
+
+func (q *sndQueue) Pop() interface{} {
+	n := len(q.items) - 1
+	x := q.items[n]
+	q.items = q.items[:n]
+	return x
+}
+
+// send put message to send queue and notify to event handler.
+// Message is sent in order of priority when sending is congested.
+// It returns false when event handler is already stopped.
+func (c *Conn) send(m RawMsg, p DRMP) bool {
+	c.sndqueue.Lock()
+	c.sndqueue.seq++
+	heap.Push(&c.sndqueue, sndItem{m: m, p: p, seq: c.sndqueue.seq})
+	c.sndqueue.Unlock()
+	return c.post(eventSndMsg{})
+}
+
+// next returns highest priority message in send queue
+func (c *Conn) next() (m RawMsg, ok bool) {
+	c.sndqueue.Lock()
+	if c.sndqueue.Len() != 0 {
+		m = heap.Pop(&c.sndqueue).(sndItem).m
+		ok = true
+	}
+	c.sndqueue.Unlock()
+	return
+}
@@ -0,0 +1,102 @@
+package diameter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	conns        = make(map[*Conn]struct{})
+	connsMutex   sync.Mutex
+	shuttingDown int32
+)
+
+const drainInterval = time.Millisecond * 100
+
+func register(c *Conn) {
+	connsMutex.Lock()
+	conns[c] = struct{}{}
+	connsMutex.Unlock()
+}
+
+func unregister(c *Conn) {
+	connsMutex.Lock()
+	delete(conns, c)
+	connsMutex.Unlock()
+}
+
+// drained returns true if the Conn has no in-flight transaction
+// or the Conn is already stopped.
+func (c *Conn) drained() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+	}
+	c.sndqueue.Lock()
+	q := c.sndqueue.Len()
+	c.sndqueue.Unlock()
+	return q == 0 && c.TxQueue() == 0 && c.RxQueue() == 0 &&
+		atomic.LoadInt32(&c.inflight) == 0
+}
+
+// Shutdown stops all connections of this node gracefully.
+// New connection is refused and new inbound request is answered
+// with DiameterTooBusy.
+// After in-flight transactions are completed,
+// DPR with the Disconnect-Cause is sent to each peer.
+// Connections that are not closed until ctx is done are closed forcibly,
+// and the ctx error is returned.
+func Shutdown(ctx context.Context, cause Enumerated) error {
+	atomic.StoreInt32(&shuttingDown, 1)
+	defer atomic.StoreInt32(&shuttingDown, 0)
+
+	connsMutex.Lock()
+	cs := make([]*Conn, 0, len(conns))
+	for c := range conns {
+		cs = append(cs, c)
+	}
+	connsMutex.Unlock()
+
+	abort := func() error {
+		for _, c := range cs {
+			c.con.Close()
+		}
+		return ctx.Err()
+	}
+
+	t := time.NewTicker(drainInterval)
+	defer t.Stop()
+	for _, c := range cs {
+		for !c.drained() {
+			select {
+			case <-ctx.Done():
+				return abort()
+			case <-t.C:
+			}
+		}
+	}
+
+	d := WDInterval
+	if dl, ok := ctx.Deadline(); ok {
+		d = time.Until(dl)
+	}
+	for _, c := range cs {
+		if s := c.getState(); s == open {
+			go c.CloseWithCause(cause, d)
+		} else if s != closing {
+			c.con.Close()
+		}
+	}
+
+	for _, c := range cs {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return abort()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+//go:build go1.21
+
+package diameter
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"sync"
+)
+
+// SlogAdapter output Notice as structured log record.
+// Use Notify method as Notify function or Subscribe callback.
+// Zero value of SlogAdapter uses slog.Default().
+type SlogAdapter struct {
+	Logger *slog.Logger
+	// Level is default log level of Notice
+	Level slog.Level
+	// ErrorLevel is log level of Notice that has error.
+	// It is used when it is higher than the level of the Notice type.
+	ErrorLevel slog.Level
+	// Dump enables full message dump at debug level
+	Dump bool
+
+	levels map[reflect.Type]slog.Level
+	mutex  sync.RWMutex
+}
+
+// NewSlogAdapter returns new SlogAdapter for the logger.
+// slog.Default() is used when l is nil.
+func NewSlogAdapter(l *slog.Logger) *SlogAdapter {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &SlogAdapter{
+		Logger:     l,
+		Level:      slog.LevelInfo,
+		ErrorLevel: slog.LevelWarn}
+}
+
+// SetLevel set log level of the Notice type,
+// for example SetLevel(MessageEvent{}, slog.LevelDebug).
+func (a *SlogAdapter) SetLevel(n Notice, l slog.Level) {
+	a.mutex.Lock()
+	if a.levels == nil {
+		a.levels = make(map[reflect.Type]slog.Level)
+	}
+	a.levels[noticeType(n)] = l
+	a.mutex.Unlock()
+}
+
+// Notify output the Notice
+func (a *SlogAdapter) Notify(n Notice) {
+	t := noticeType(n)
+	a.mutex.RLock()
+	l, ok := a.levels[t]
+	a.mutex.RUnlock()
+	if !ok {
+		l = a.Level
+	}
+
+	attrs := make([]slog.Attr, 0, 16)
+	attrs = append(attrs, slog.String("event", t.Name()))
+	var c *Conn
+	var m RawMsg
+	var e error
+
+	switch n := n.(type) {
+	case StateUpdate:
+		c, e = n.Conn, n.Err
+		attrs = append(attrs,
+			slog.String("trigger", n.Event),
+			slog.String("state.old", n.OldState.String()),
+			slog.String("state.new", n.NewState.String()))
+	case CapabilityExchangeEvent:
+		c, m, e = n.Conn, n.Msg, n.Err
+		attrs = append(attrs, slog.Bool("tx", n.Tx))
+	case WatchdogEvent:
+		c, m, e = n.Conn, n.Msg, n.Err
+		attrs = append(attrs, slog.Bool("tx", n.Tx))
+	case MessageEvent:
+		c, m, e = n.Conn, n.Msg, n.Err
+		attrs = append(attrs, slog.Bool("tx", n.Tx))
+	case PurgeEvent:
+		c, m, e = n.Conn, n.Msg, n.Err
+		attrs = append(attrs, slog.Bool("tx", n.Tx))
+	case DuplicateEvent:
+		c, m, e = n.Conn, n.Msg, n.Err
+		attrs = append(attrs, slog.Bool("replay", n.Replay))
+	case AdmissionEvent:
+		c = n.Conn
+		attrs = append(attrs, slog.Bool("shed", n.Shed))
+	case BreakerEvent:
+		c = n.Conn
+		attrs = append(attrs,
+			slog.String("state.old", n.OldState.String()),
+			slog.String("state.new", n.NewState.String()))
+	case OverloadEvent:
+		c = n.Conn
+		attrs = append(attrs,
+			slog.String("node", string(n.Node)),
+			slog.String("report", n.ReportType.String()),
+			slog.Uint64("sequence", n.SequenceNumber),
+			slog.Uint64("reduction", uint64(n.ReductionPercentage)),
+			slog.Duration("validity", n.ValidityDuration))
+	case PeerRebootEvent:
+		c = n.Conn
+		attrs = append(attrs,
+			slog.String("host", string(n.Host)),
+			slog.Uint64("state-id.old", uint64(n.OldID)),
+			slog.Uint64("state-id.new", uint64(n.NewID)))
+	case StateFileEvent:
+		e = n.Err
+	}
+
+	if c != nil && c.Peer != nil {
+		attrs = append(attrs,
+			slog.String("peer.host", string(c.Peer.Host)),
+			slog.String("peer.realm", string(c.Peer.Realm)))
+	}
+	if m.Code != 0 {
+		attrs = append(attrs,
+			slog.Bool("request", m.FlgR),
+			slog.Uint64("cmd", uint64(m.Code)),
+			slog.Uint64("app", uint64(m.AppID)),
+			slog.Uint64("hbh", uint64(m.HbHID)),
+			slog.Uint64("ete", uint64(m.EtEID)))
+		for _, avp := range m.AVP {
+			if avp.Code == 263 && avp.VenID == 0 {
+				if s, err := GetSessionID(avp); err == nil {
+					attrs = append(attrs, slog.String("session", s))
+				}
+				break
+			}
+		}
+		if !m.FlgR {
+			attrs = append(attrs, slog.Uint64("result", uint64(getResult(m))))
+		}
+	}
+	if e != nil {
+		attrs = append(attrs, slog.String("error", e.Error()))
+		if a.ErrorLevel > l {
+			l = a.ErrorLevel
+		}
+	}
+
+	lg := a.Logger
+	if lg == nil {
+		lg = slog.Default()
+	}
+	ctx := context.Background()
+	lg.LogAttrs(ctx, l, n.String(), attrs...)
+	if a.Dump && m.Code != 0 && lg.Enabled(ctx, slog.LevelDebug) {
+		lg.LogAttrs(ctx, slog.LevelDebug, "message dump",
+			slog.Uint64("hbh", uint64(m.HbHID)),
+			slog.Uint64("ete", uint64(m.EtEID)),
+			slog.String("dump", FormatMsg(m)))
+	}
+}
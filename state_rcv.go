@@ -1,6 +1,7 @@
 package diameter
 
 import (
+	"sync/atomic"
 	"time"
 )
 
@@ -14,23 +15,24 @@ func (eventRcvCER) String() string {
 }
 
 func (v eventRcvCER) exec(c *Conn) error {
-	c.RxReq++
+	c.count(false, v.m)
 	if c.state != waitCER {
-		c.Reject++
+		atomic.AddUint64(&c.Reject, 1)
 		return NotAcceptableEvent{stateEvent: v, state: c.state}
 	}
 
 	cer, _, e := CER{}.FromRaw(v.m)
-	Notify(CapabilityExchangeEvent{tx: false, req: true, conn: c, Err: e})
+	publish(CapabilityExchangeEvent{Tx: false, Req: true, Conn: c, Msg: v.m, Err: e})
 
 	if e != nil {
 		// ToDo
 		// make error answer for undecodable CER
-		c.Reject++
+		atomic.AddUint64(&c.Reject, 1)
 		c.con.Close()
 		return e
 	}
 
+	checkStateID(c, cer.(CER).OriginHost, cer.(CER).OriginStateID)
 	cea := HandleCER(cer.(CER), c)
 	m := cea.ToRaw("")
 	m.HbHID = v.m.HbHID
@@ -39,6 +41,7 @@ func (v eventRcvCER) exec(c *Conn) error {
 		m.FlgE = true
 	}
 	c.con.SetWriteDeadline(time.Now().Add(TransportTimeout))
+	c.count(true, m)
 	_, e = m.WriteTo(c.con)
 
 	if e == nil && cea.ResultCode != DiameterSuccess {
@@ -50,7 +53,7 @@ func (v eventRcvCER) exec(c *Conn) error {
 		c.Since = time.Now()
 	}
 
-	Notify(CapabilityExchangeEvent{tx: true, req: false, conn: c, Err: e})
+	publish(CapabilityExchangeEvent{Tx: true, Req: false, Conn: c, Msg: m, Err: e})
 	if e != nil {
 		c.con.Close()
 	}
@@ -78,6 +81,7 @@ func (v eventRcvCEA) exec(c *Conn) error {
 
 	cea, _, e := CEA{}.FromRaw(v.m)
 	if e == nil {
+		checkStateID(c, cea.(CEA).OriginHost, cea.(CEA).OriginStateID)
 		HandleCEA(cea.(CEA), c)
 		if cea.Result() == DiameterSuccess {
 			c.state = open
@@ -88,7 +92,7 @@ func (v eventRcvCEA) exec(c *Conn) error {
 		}
 	}
 
-	Notify(CapabilityExchangeEvent{tx: false, req: false, conn: c, Err: e})
+	publish(CapabilityExchangeEvent{Tx: false, Req: false, Conn: c, Msg: v.m, Err: e})
 	if e != nil {
 		c.con.Close()
 		v.m = RawMsg{}
@@ -106,22 +110,23 @@ func (eventRcvDWR) String() string {
 }
 
 func (v eventRcvDWR) exec(c *Conn) error {
-	c.RxReq++
+	c.count(false, v.m)
 	if c.state != open {
-		c.Reject++
+		atomic.AddUint64(&c.Reject, 1)
 		return NotAcceptableEvent{stateEvent: v, state: c.state}
 	}
 
 	dwr, _, e := DWR{}.FromRaw(v.m)
-	Notify(WatchdogEvent{tx: false, req: true, conn: c, Err: e})
+	publish(WatchdogEvent{Tx: false, Req: true, Conn: c, Msg: v.m, Err: e})
 
 	if e != nil {
 		// ToDo
 		// make error answer for undecodable CER
-		c.Reject++
+		atomic.AddUint64(&c.Reject, 1)
 		return e
 	}
 
+	checkStateID(c, dwr.(DWR).OriginHost, dwr.(DWR).OriginStateID)
 	dwa := HandleDWR(dwr.(DWR), c)
 	m := dwa.ToRaw("")
 	m.HbHID = v.m.HbHID
@@ -130,6 +135,7 @@ func (v eventRcvDWR) exec(c *Conn) error {
 		m.FlgE = true
 	}
 	c.con.SetWriteDeadline(time.Now().Add(TransportTimeout))
+	c.count(true, m)
 	_, e = m.WriteTo(c.con)
 
 	if e == nil && dwa.ResultCode != DiameterSuccess {
@@ -140,7 +146,7 @@ func (v eventRcvDWR) exec(c *Conn) error {
 		c.wdTimer.Reset(c.Peer.WDInterval)
 	}
 
-	Notify(WatchdogEvent{tx: true, req: false, conn: c, Err: e})
+	publish(WatchdogEvent{Tx: true, Req: false, Conn: c, Msg: m, Err: e})
 	if e != nil {
 		c.con.Close()
 	}
@@ -168,6 +174,7 @@ func (v eventRcvDWA) exec(c *Conn) error {
 
 	dwa, _, e := DWA{}.FromRaw(v.m)
 	if e == nil {
+		checkStateID(c, dwa.(DWA).OriginHost, dwa.(DWA).OriginStateID)
 		HandleDWA(dwa.(DWA), c)
 		if dwa.Result() == uint32(DiameterSuccess) {
 			c.wdCount = 0
@@ -178,7 +185,7 @@ func (v eventRcvDWA) exec(c *Conn) error {
 	c.wdTimer.Stop()
 	c.wdTimer.Reset(c.Peer.WDInterval)
 
-	Notify(WatchdogEvent{tx: false, req: false, conn: c, Err: e})
+	publish(WatchdogEvent{Tx: false, Req: false, Conn: c, Msg: v.m, Err: e})
 	if e != nil {
 		v.m = RawMsg{}
 	}
@@ -195,19 +202,19 @@ func (eventRcvDPR) String() string {
 }
 
 func (v eventRcvDPR) exec(c *Conn) error {
-	c.RxReq++
+	c.count(false, v.m)
 	if c.state != open {
-		c.Reject++
+		atomic.AddUint64(&c.Reject, 1)
 		return NotAcceptableEvent{stateEvent: v, state: c.state}
 	}
 
 	dpr, _, e := DPR{}.FromRaw(v.m)
-	Notify(PurgeEvent{tx: false, req: true, conn: c, Err: e})
+	publish(PurgeEvent{Tx: false, Req: true, Conn: c, Msg: v.m, Err: e})
 
 	if e != nil {
 		// ToDo
 		// make error answer for undecodable CER
-		c.Reject++
+		atomic.AddUint64(&c.Reject, 1)
 		return e
 	}
 
@@ -226,9 +233,10 @@ func (v eventRcvDPR) exec(c *Conn) error {
 		})
 	}
 	c.con.SetWriteDeadline(time.Now().Add(TransportTimeout))
+	c.count(true, m)
 	_, e = m.WriteTo(c.con)
 
-	Notify(&PurgeEvent{tx: true, req: false, conn: c, Err: e})
+	publish(PurgeEvent{Tx: true, Req: false, Conn: c, Msg: m, Err: e})
 	if e != nil {
 		c.con.Close()
 	}
@@ -261,7 +269,7 @@ func (v eventRcvDPA) exec(c *Conn) error {
 		}
 	}
 
-	Notify(PurgeEvent{tx: false, req: false, conn: c, Err: e})
+	publish(PurgeEvent{Tx: false, Req: false, Conn: c, Msg: v.m, Err: e})
 	c.con.Close()
 	if e != nil {
 		v.m = RawMsg{}
@@ -278,35 +286,67 @@ func (eventRcvMsg) String() string {
 	return "Rcv-MSG"
 }
 
+// acceptable returns result code to reject the request,
+// or 0 when the request is acceptable
+func (c *Conn) acceptable(m RawMsg) uint32 {
+	var cause uint32
+	if app, ok := supportedApps[m.AppID]; !ok {
+		cause = DiameterApplicationUnsupported
+	} else if _, ok = app.req[m.Code]; !ok {
+		cause = DiameterCommandUnspported
+	}
+	if cause != 0 {
+		if app, ok := supportedApps[0xffffffff]; ok {
+			if _, ok = app.req[0]; ok {
+				cause = 0
+			}
+		}
+	}
+
+	switch {
+	case cause != 0:
+	case atomic.LoadInt32(&shuttingDown) != 0:
+		cause = DiameterTooBusy
+	case !c.admit(m):
+		atomic.AddUint64(&c.RxBusy, 1)
+		cause = DiameterTooBusy
+	}
+	return cause
+}
+
 func (v eventRcvMsg) exec(c *Conn) (e error) {
 
 	if v.m.FlgR {
-		c.RxReq++
+		c.count(false, v.m)
 		if c.state != open {
-			c.Reject++
+			atomic.AddUint64(&c.Reject, 1)
 			return NotAcceptableEvent{stateEvent: v, state: c.state}
 		}
 
 		var cause uint32
-
-		if app, ok := supportedApps[v.m.AppID]; !ok {
-			cause = DiameterApplicationUnsupported
-		} else if _, ok = app.req[v.m.Code]; !ok {
-			cause = DiameterCommandUnspported
+		ans, dup := checkDuplicate(c, v.m)
+		if !dup {
+			cause = c.acceptable(v.m)
 		}
 
-		if cause == 0 {
-		} else if app, ok := supportedApps[0xffffffff]; !ok {
-		} else if _, ok = app.req[0]; ok {
-			cause = 0
-		}
-
-		if cause != 0 {
+		if dup {
+			if ans.Code != 0 {
+				ans.HbHID = v.m.HbHID
+				c.con.SetWriteDeadline(time.Now().Add(TransportTimeout))
+				c.count(true, ans)
+				_, e = ans.WriteTo(c.con)
+			}
+			publish(DuplicateEvent{Replay: ans.Code != 0, Conn: c, Msg: v.m, Err: e})
+		} else if cause != 0 {
 			req, sid, _ := GenericReq{}.FromRaw(v.m)
 			a := req.Failed(cause).ToRaw(sid)
 			a.HbHID = v.m.HbHID
 			a.EtEID = v.m.EtEID
+			setOCAnswer(c, v.m, &a)
+			setLoadAnswer(c, &a)
+			storeAnswer(v.m, a)
 			c.con.SetWriteDeadline(time.Now().Add(TransportTimeout))
+			c.count(true, a)
 			_, e = a.WriteTo(c.con)
 		} else {
 			c.rcvstack <- v.m
@@ -315,6 +355,10 @@ func (v eventRcvMsg) exec(c *Conn) (e error) {
 		if c.state != open {
 			return NotAcceptableEvent{stateEvent: v, state: c.state}
 		}
+		if OCFeatureVector != 0 {
+			// overload report is used even if the answer is too late
+			handleOLR(c, v.m)
+		}
 
 		ch, ok := c.sndstack[v.m.HbHID]
 		if !ok {
@@ -326,7 +370,7 @@ func (v eventRcvMsg) exec(c *Conn) (e error) {
 	c.wdTimer.Stop()
 	c.wdTimer.Reset(c.Peer.WDInterval)
 
-	Notify(MessageEvent{tx: false, req: v.m.FlgR, conn: c, Err: e})
+	publish(MessageEvent{Tx: false, Req: v.m.FlgR, Conn: c, Msg: v.m, Err: e})
 	if e != nil {
 		c.con.Close()
 	}
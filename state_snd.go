@@ -4,9 +4,12 @@ import (
 	"time"
 )
 
-type state int
+// ConnState is state of Conn
+type ConnState int
 
-func (s state) String() string {
+type state = ConnState
+
+func (s ConnState) String() string {
 	switch s {
 	case shutdown:
 		return "shutdown"
@@ -33,6 +36,16 @@ const (
 	closing
 )
 
+// State of Conn
+const (
+	StateShutdown = shutdown
+	StateClosed   = closed
+	StateWaitCER  = waitCER
+	StateWaitCEA  = waitCEA
+	StateOpen     = open
+	StateClosing  = closing
+)
+
 type stateEvent interface {
 	exec(p *Conn) error
 	String() string
@@ -64,10 +77,10 @@ func (v eventConnect) exec(c *Conn) error {
 	}
 	c.state = waitCEA
 
-	c.TxReq++
+	c.count(true, v.m)
 	c.con.SetWriteDeadline(time.Now().Add(TransportTimeout))
 	_, e := v.m.WriteTo(c.con)
-	Notify(CapabilityExchangeEvent{tx: true, req: true, conn: c, Err: e})
+	publish(CapabilityExchangeEvent{Tx: true, Req: true, Conn: c, Msg: v.m, Err: e})
 	if e != nil {
 		c.con.Close()
 	}
@@ -94,10 +107,10 @@ func (v eventWatchdog) exec(c *Conn) error {
 		return WatchdogExpired{}
 	}
 
-	c.TxReq++
+	c.count(true, v.m)
 	c.con.SetWriteDeadline(time.Now().Add(TransportTimeout))
 	_, e := v.m.WriteTo(c.con)
-	Notify(WatchdogEvent{tx: true, req: true, conn: c, Err: e})
+	publish(WatchdogEvent{Tx: true, Req: true, Conn: c, Msg: v.m, Err: e})
 	if e != nil {
 		c.con.Close()
 	}
@@ -122,10 +135,10 @@ func (v eventStop) exec(c *Conn) error {
 	c.wdTimer.Stop()
 	c.Since = time.Time{}
 
-	c.TxReq++
+	c.count(true, v.m)
 	c.con.SetWriteDeadline(time.Now().Add(TransportTimeout))
 	_, e := v.m.WriteTo(c.con)
-	Notify(PurgeEvent{tx: true, req: true, conn: c, Err: e})
+	publish(PurgeEvent{Tx: true, Req: true, Conn: c, Msg: v.m, Err: e})
 	if e != nil {
 		c.con.Close()
 	}
@@ -143,6 +156,9 @@ func (v eventPeerDisc) exec(c *Conn) error {
 	c.con.Close()
 	c.state = closed
 	c.Since = time.Time{}
+	if c.wdTimer != nil {
+		c.wdTimer.Stop()
+	}
 
 	for _, ch := range c.sndstack {
 		ch <- RawMsg{}
@@ -153,23 +169,25 @@ func (v eventPeerDisc) exec(c *Conn) error {
 }
 
 // Snd MSG
-type eventSndMsg struct {
-	m RawMsg
-}
+type eventSndMsg struct{}
 
 func (eventSndMsg) String() string {
 	return "Snd-MSG"
 }
 
 func (v eventSndMsg) exec(c *Conn) error {
+	m, ok := c.next()
+	if !ok {
+		return nil
+	}
 	if c.state != open {
 		return NotAcceptableEvent{stateEvent: v, state: c.state}
 	}
 
-	c.TxReq++
+	c.count(true, m)
 	c.con.SetWriteDeadline(time.Now().Add(TransportTimeout))
-	_, e := v.m.WriteTo(c.con)
-	Notify(MessageEvent{tx: true, req: v.m.FlgR, conn: c, Err: e})
+	_, e := m.WriteTo(c.con)
+	publish(MessageEvent{Tx: true, Req: m.FlgR, Conn: c, Msg: m, Err: e})
 	if e != nil {
 		c.con.Close()
 	}
@@ -0,0 +1,125 @@
+package diameter
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// InstanceID is optional value of Session-Id.
+	// It distinguishes instances that have same Origin-Host.
+	// Session-Id is unique across the instances only when each of them
+	// sets different InstanceID.
+	// When it is empty, 64bit random value from crypto/rand is used,
+	// so collision of Session-Id between the instances is not impossible
+	// but its probability is negligible.
+	InstanceID string
+
+	instanceID string
+	stateFile  string
+	eteReserve uint32
+
+	stateSave   = make(chan struct{}, 1)
+	stateWriter sync.Once
+)
+
+// eteBlock is number of End-to-End ID that is reserved in state file.
+// Next block is reserved when half of the block is used,
+// so that the state file is written in background.
+const eteBlock = 0x10000
+
+type nodeState struct {
+	OriginStateID uint32 `json:"origin-state-id"`
+	EndToEndID    uint32 `json:"end-to-end-id"`
+}
+
+// LoadStateFile read node state from the file and update it.
+// Origin-State-Id is incremented from the stored value,
+// so Session-Id of this instance is unique across restarts,
+// and End-to-End ID is started from the reserved value.
+// The file is created when it does not exist.
+// It must be called before any connection is established.
+func LoadStateFile(path string) error {
+	s := nodeState{}
+	if b, e := os.ReadFile(path); e == nil {
+		if e = json.Unmarshal(b, &s); e != nil {
+			return e
+		}
+	} else if !os.IsNotExist(e) {
+		return e
+	}
+
+	if s.OriginStateID != 0 {
+		StateID = s.OriginStateID + 1
+		if StateID == 0 {
+			StateID = 1
+		}
+	}
+
+	ete := <-etEID
+	if s.EndToEndID != 0 {
+		ete = s.EndToEndID
+	}
+	stateFile = path
+	atomic.StoreUint32(&eteReserve, ete+eteBlock)
+	e := saveState()
+	etEID <- ete
+
+	stateWriter.Do(func() {
+		go func() {
+			for range stateSave {
+				if e := saveState(); e != nil {
+					publish(StateFileEvent{Err: e})
+				}
+			}
+		}()
+	})
+	return e
+}
+
+// reserveEtE reserve next block of End-to-End ID when half of
+// current block is used, and request to write the state file.
+// It must be called while End-to-End ID is locked.
+func reserveEtE(id uint32) {
+	r := atomic.LoadUint32(&eteReserve)
+	if len(stateFile) == 0 || id != r-eteBlock/2 {
+		return
+	}
+	atomic.StoreUint32(&eteReserve, r+eteBlock)
+	select {
+	case stateSave <- struct{}{}:
+	default:
+	}
+}
+
+// saveState write node state to the state file.
+func saveState() error {
+	b, e := json.Marshal(nodeState{
+		OriginStateID: StateID,
+		EndToEndID:    atomic.LoadUint32(&eteReserve)})
+	if e != nil {
+		return e
+	}
+	tmp := stateFile + ".tmp"
+	if e = os.WriteFile(tmp, b, 0644); e != nil {
+		return e
+	}
+	return os.Rename(tmp, stateFile)
+}
+
+// randomInstanceID returns default instance ID of Session-Id.
+// Process ID and time is used when crypto/rand is not available.
+func randomInstanceID() string {
+	b := make([]byte, 8)
+	if _, e := crand.Read(b); e != nil {
+		binary.BigEndian.PutUint64(b,
+			uint64(os.Getpid())<<32^uint64(time.Now().UnixNano()))
+	}
+	return hex.EncodeToString(b)
+}
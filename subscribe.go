@@ -0,0 +1,150 @@
+package diameter
+
+import (
+	"reflect"
+	"sync"
+)
+
+type subscription struct {
+	conn  *Conn
+	types map[reflect.Type]bool
+	f     func(Notice)
+}
+
+var (
+	subs     []*subscription
+	subMutex sync.Mutex
+)
+
+// Subscribe register callback function for Notice of this node.
+// Only Notice of given types is passed when types are specified,
+// for example Subscribe(f, StateUpdate{}, MessageEvent{}).
+// Pointer of Notice such as &PurgeEvent{} is same as its value.
+// The function is called in event handler, so it must not be blocked.
+// Returned function cancels the subscription.
+func Subscribe(f func(Notice), types ...Notice) func() {
+	return subscribe(nil, f, types)
+}
+
+// SubscribeChan is same as Subscribe, but Notice is sent to the channel.
+// Notice is dropped when the channel is full.
+func SubscribeChan(ch chan<- Notice, types ...Notice) func() {
+	return subscribe(nil, chanFunc(ch), types)
+}
+
+// Subscribe register callback function for Notice of the Conn.
+// Subscription is cancelled automatically when the Conn is closed.
+func (c *Conn) Subscribe(f func(Notice), types ...Notice) func() {
+	return subscribe(c, f, types)
+}
+
+// SubscribeChan is same as Subscribe of the Conn,
+// but Notice is sent to the channel.
+// Notice is dropped when the channel is full.
+func (c *Conn) SubscribeChan(ch chan<- Notice, types ...Notice) func() {
+	return subscribe(c, chanFunc(ch), types)
+}
+
+func chanFunc(ch chan<- Notice) func(Notice) {
+	return func(n Notice) {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
+
+func subscribe(c *Conn, f func(Notice), types []Notice) func() {
+	s := &subscription{conn: c, f: f}
+	if len(types) != 0 {
+		s.types = make(map[reflect.Type]bool, len(types))
+		for _, t := range types {
+			s.types[noticeType(t)] = true
+		}
+	}
+
+	subMutex.Lock()
+	ss := make([]*subscription, len(subs), len(subs)+1)
+	copy(ss, subs)
+	subs = append(ss, s)
+	subMutex.Unlock()
+
+	return func() {
+		unsubscribe(func(v *subscription) bool { return v == s })
+	}
+}
+
+func unsubscribe(match func(*subscription) bool) {
+	subMutex.Lock()
+	ss := make([]*subscription, 0, len(subs))
+	for _, v := range subs {
+		if !match(v) {
+			ss = append(ss, v)
+		}
+	}
+	subs = ss
+	subMutex.Unlock()
+}
+
+// noticeType returns type of the Notice,
+// so that pointer of Notice matches same type of value.
+func noticeType(n Notice) reflect.Type {
+	t := reflect.TypeOf(n)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// noticeConn returns Conn that is related to the Notice
+func noticeConn(n Notice) *Conn {
+	switch n := n.(type) {
+	case StateUpdate:
+		return n.Conn
+	case CapabilityExchangeEvent:
+		return n.Conn
+	case WatchdogEvent:
+		return n.Conn
+	case MessageEvent:
+		return n.Conn
+	case PurgeEvent:
+		return n.Conn
+	case DuplicateEvent:
+		return n.Conn
+	case AdmissionEvent:
+		return n.Conn
+	case BreakerEvent:
+		return n.Conn
+	case OverloadEvent:
+		return n.Conn
+	case PeerRebootEvent:
+		return n.Conn
+	}
+	return nil
+}
+
+// publish pass the Notice to Notify and subscribers
+func publish(n Notice) {
+	if Notify != nil {
+		Notify(n)
+	}
+
+	subMutex.Lock()
+	ss := subs
+	subMutex.Unlock()
+	if len(ss) == 0 {
+		return
+	}
+
+	c := noticeConn(n)
+	t := noticeType(n)
+	for _, s := range ss {
+		if s.conn != nil && s.conn != c {
+			continue
+		}
+		if s.types != nil && !s.types[t] {
+			continue
+		}
+		s.f(n)
+	}
+}
@@ -0,0 +1,464 @@
+package diameter
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+MarshalText returns the message in text format.
+Text format is line based format for message template and review.
+
+	# Send-Routing-Info-for-SM request
+	Send-Routing-Info-for-SM-Request [RP] hbh=0x00000001 ete=0x00000001
+	Session-Id = "host.example.com;1;1"
+	Vendor-Specific-Application-Id {
+	  Vendor-Id = 10415
+	  Auth-Application-Id = 16777312
+	}
+	Auth-Session-State = NO_STATE_MAINTAINED
+	Origin-Host = "host.example.com"
+	1234:10415 [VM] = 0x0102
+
+First line is header that has command and header fields.
+Command is command name in dictionary with "-Request" or "-Answer",
+or Command-Code number. Fields of header are
+
+	[flags]  R, P, E and T flag that is set
+	app=n    Application-ID (default is application of the command)
+	hbh=n    Hop-by-Hop ID (default is 0)
+	ete=n    End-to-End ID (default is 0)
+	ver=n    Version (default is 1)
+
+R flag is set by default for request command name, and other flags
+are not set by default.
+
+Following lines are AVPs. AVP is AVP name in dictionary or "code:vendor",
+optional [flags] of V, M and P flag that is set, and value.
+Default flags are flags of dictionary. Value is
+
+	= "text"   quoted string in Go syntax
+	= text     text without space as ValueString
+	= 0x0102   data in hex
+	{ ... }    AVPs in Grouped AVP that are closed by "}" line
+
+Quoted and not quoted text is converted by data type of the AVP, so
+numbers, addresses and time are also writable as quoted text.
+Quoted text of OctetString is used as data itself.
+Enumerated value can be written by name in dictionary.
+Text after "#" is comment.
+
+UnmarshalText also accepts dump of RawMsg.String().
+*/
+func (m RawMsg) MarshalText() ([]byte, error) {
+	w := new(bytes.Buffer)
+
+	name := strconv.FormatUint(uint64(m.Code), 10)
+	r, app := false, uint32(0)
+	if c, ok := LookupCommand(m.AppID, m.Code); ok {
+		if n, ok := LookupCommandByName(c.Name); ok && n.Code == c.Code {
+			name, r, app = c.Name, m.FlgR, n.AppID
+			if m.FlgR {
+				name += "-Request"
+			} else {
+				name += "-Answer"
+			}
+		}
+	}
+	w.WriteString(name)
+	if m.FlgR != r || m.FlgP || m.FlgE || m.FlgT {
+		fmt.Fprintf(w, " [%s]", jsonFlags("RPET", m.FlgR, m.FlgP, m.FlgE, m.FlgT))
+	}
+	if m.AppID != app {
+		fmt.Fprintf(w, " app=%d", m.AppID)
+	}
+	fmt.Fprintf(w, " hbh=0x%08x ete=0x%08x", m.HbHID, m.EtEID)
+	if m.Ver != 1 {
+		fmt.Fprintf(w, " ver=%d", m.Ver)
+	}
+	w.WriteString("\n")
+
+	for _, a := range m.AVP {
+		writeTextAVP(w, a, "")
+	}
+	return w.Bytes(), nil
+}
+
+func textDefaultAVP(d DictAVP) RawAVP {
+	a := RawAVP{Code: d.Code, VenID: d.VenID, FlgV: d.VenID != 0}
+	if len(d.Name) != 0 {
+		a.FlgM = d.Mandatory == FlagMust
+		a.FlgP = d.Protected == FlagMust
+	}
+	return a
+}
+
+func writeTextAVP(w *bytes.Buffer, a RawAVP, indent string) {
+	d, known := LookupAVP(a.Code, a.VenID)
+	if known {
+		if n, ok := LookupAVPByName(d.Name); !ok || n.Code != d.Code || n.VenID != d.VenID {
+			known = false
+		}
+	}
+	if !known {
+		d = DictAVP{Code: a.Code, VenID: a.VenID, Type: "OctetString"}
+	}
+
+	w.WriteString(indent)
+	switch {
+	case known:
+		w.WriteString(d.Name)
+	case a.VenID != 0:
+		fmt.Fprintf(w, "%d:%d", a.Code, a.VenID)
+	default:
+		fmt.Fprintf(w, "%d", a.Code)
+	}
+	if t := textDefaultAVP(d); t.FlgV != a.FlgV || t.FlgM != a.FlgM || t.FlgP != a.FlgP {
+		fmt.Fprintf(w, " [%s]", jsonFlags("VMP", a.FlgV, a.FlgM, a.FlgP))
+	}
+
+	if known && d.Type == "Grouped" {
+		var g []RawAVP
+		t := RawAVP{}
+		if a.Decode(&g) == nil && t.Encode(g) == nil && bytes.Equal(t.data, a.data) {
+			if len(g) == 0 {
+				w.WriteString(" {}\n")
+				return
+			}
+			w.WriteString(" {\n")
+			for _, c := range g {
+				writeTextAVP(w, c, indent+"  ")
+			}
+			w.WriteString(indent + "}\n")
+			return
+		}
+	} else if known && d.Type != "OctetString" {
+		if s, ok := textValue(a, d); ok {
+			fmt.Fprintf(w, " = %s\n", s)
+			return
+		}
+	}
+	fmt.Fprintf(w, " = 0x%s\n", hex.EncodeToString(a.data))
+}
+
+// textValue returns text of the value that is converted to same data
+func textValue(a RawAVP, d DictAVP) (string, bool) {
+	s, e := valueString(a, d.Type)
+	if e != nil {
+		return "", false
+	}
+	if d.Type == "Enumerated" {
+		if v, e := strconv.ParseInt(s, 10, 64); e == nil {
+			if n, ok := d.Enum[v]; ok && len(n) != 0 {
+				s = n
+			}
+		}
+	}
+	t := RawAVP{}
+	if t.setValueString(s, d) != nil || !bytes.Equal(t.data, a.data) {
+		return "", false
+	}
+	switch d.Type {
+	case "UTF8String", "DiameterIdentity", "DiameterURI",
+		"IPFilterRule", "QoSFilterRule":
+		return strconv.Quote(s), true
+	}
+	if len(s) == 0 || strings.ContainsAny(s, " \t\"#{}[]=") {
+		return strconv.Quote(s), true
+	}
+	return s, true
+}
+
+// UnmarshalText set the message from text format or dump of String()
+func (m *RawMsg) UnmarshalText(b []byte) error {
+	lines := strings.Split(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n")
+	for _, l := range lines {
+		l = strings.TrimSpace(stripComment(l))
+		if len(l) == 0 {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(l, Indent+" "), "Version") &&
+			strings.Contains(l, "=") {
+			return m.parseDump(lines)
+		}
+		break
+	}
+
+	type frame struct {
+		avp  RawAVP
+		list []RawAVP
+	}
+	var stack []frame
+	var avps []RawAVP
+	header := false
+
+	for i, l := range lines {
+		l = strings.TrimSpace(stripComment(l))
+		if len(l) == 0 {
+			continue
+		}
+		if !header {
+			if e := m.parseTextHeader(l); e != nil {
+				return fmt.Errorf("line %d: %v", i+1, e)
+			}
+			header = true
+			continue
+		}
+		if l == "}" {
+			if len(stack) == 0 {
+				return fmt.Errorf("line %d: unexpected }", i+1)
+			}
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			f.avp.Encode(avps)
+			avps = append(f.list, f.avp)
+			continue
+		}
+
+		a, v, e := parseTextAVP(l)
+		if e != nil {
+			return fmt.Errorf("line %d: %v", i+1, e)
+		}
+		if v == "{" {
+			stack = append(stack, frame{avp: a, list: avps})
+			avps = []RawAVP{}
+		} else {
+			avps = append(avps, a)
+		}
+	}
+	if !header {
+		return fmt.Errorf("no message header")
+	}
+	if len(stack) != 0 {
+		return fmt.Errorf("grouped AVP is not closed")
+	}
+	m.AVP = avps
+	return nil
+}
+
+// stripComment removes text after # that is not in quoted string
+func stripComment(l string) string {
+	quote := false
+	for i := 0; i < len(l); i++ {
+		switch l[i] {
+		case '\\':
+			if quote {
+				i++
+			}
+		case '"':
+			quote = !quote
+		case '#':
+			if !quote {
+				return l[:i]
+			}
+		}
+	}
+	return l
+}
+
+func (m *RawMsg) parseTextHeader(l string) error {
+	t := strings.Fields(l)
+	*m = RawMsg{Ver: 1}
+
+	if c, e := strconv.ParseUint(t[0], 10, 32); e == nil {
+		m.Code = uint32(c)
+	} else {
+		name := strings.TrimSuffix(t[0], "-Answer")
+		if strings.HasSuffix(t[0], "-Request") {
+			name = strings.TrimSuffix(t[0], "-Request")
+			m.FlgR = true
+		} else if name == t[0] {
+			return fmt.Errorf("command %s must have -Request or -Answer", t[0])
+		}
+		c, ok := LookupCommandByName(name)
+		if !ok {
+			return fmt.Errorf("unknown command %s", name)
+		}
+		m.Code, m.AppID = c.Code, c.AppID
+	}
+
+	for _, f := range t[1:] {
+		if strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]") {
+			m.FlgR, m.FlgP, m.FlgE, m.FlgT = false, false, false, false
+			for _, c := range f[1 : len(f)-1] {
+				switch c {
+				case 'R':
+					m.FlgR = true
+				case 'P':
+					m.FlgP = true
+				case 'E':
+					m.FlgE = true
+				case 'T':
+					m.FlgT = true
+				default:
+					return fmt.Errorf("invalid command flag %c", c)
+				}
+			}
+			continue
+		}
+		i := strings.IndexByte(f, '=')
+		if i < 0 {
+			return fmt.Errorf("invalid header field %s", f)
+		}
+		v, e := strconv.ParseUint(f[i+1:], 0, 32)
+		if e != nil {
+			return fmt.Errorf("invalid value of %s", f)
+		}
+		switch f[:i] {
+		case "app":
+			m.AppID = uint32(v)
+		case "hbh":
+			m.HbHID = uint32(v)
+		case "ete":
+			m.EtEID = uint32(v)
+		case "ver":
+			if v > 255 {
+				return fmt.Errorf("invalid version %d", v)
+			}
+			m.Ver = uint8(v)
+		default:
+			return fmt.Errorf("unknown header field %s", f[:i])
+		}
+	}
+	return nil
+}
+
+// parseTextAVP returns AVP of the line, and "{" when the AVP is opened
+// Grouped AVP.
+func parseTextAVP(l string) (a RawAVP, v string, e error) {
+	i := strings.IndexAny(l, " \t=[{")
+	if i < 0 {
+		return a, "", fmt.Errorf("no value of %s", l)
+	}
+	d, e := lookupPathName(l[:i])
+	if e != nil {
+		return
+	}
+	a = textDefaultAVP(d)
+
+	v = strings.TrimSpace(l[i:])
+	if strings.HasPrefix(v, "[") {
+		j := strings.IndexByte(v, ']')
+		if j < 0 {
+			return a, "", fmt.Errorf("unclosed flags")
+		}
+		a.FlgV, a.FlgM, a.FlgP = false, false, false
+		for _, c := range v[1:j] {
+			switch c {
+			case 'V':
+				a.FlgV = true
+			case 'M':
+				a.FlgM = true
+			case 'P':
+				a.FlgP = true
+			default:
+				return a, "", fmt.Errorf("invalid AVP flag %c", c)
+			}
+		}
+		v = strings.TrimSpace(v[j+1:])
+	}
+
+	switch {
+	case v == "{":
+		return a, v, nil
+	case strings.ReplaceAll(v, " ", "") == "{}":
+		a.data = []byte{}
+		return a, "", nil
+	case !strings.HasPrefix(v, "="):
+		return a, "", fmt.Errorf("no value of %s", l[:i])
+	}
+
+	v = strings.TrimSpace(v[1:])
+	switch {
+	case strings.HasPrefix(v, "0x"):
+		a.data, e = hex.DecodeString(v[2:])
+	case strings.HasPrefix(v, "\""):
+		var s string
+		if s, e = strconv.Unquote(v); e != nil {
+		} else if d.Type == "OctetString" {
+			a.data = []byte(s)
+		} else {
+			e = a.setValueString(s, d)
+		}
+	default:
+		e = a.setValueString(v, d)
+	}
+	if e != nil {
+		e = fmt.Errorf("invalid value %s of %s: %v", v, l[:i], e)
+	}
+	return a, "", e
+}
+
+// parseDump parse text of RawMsg.String()
+func (m *RawMsg) parseDump(lines []string) error {
+	*m = RawMsg{}
+	var a *RawAVP
+	for i, l := range lines {
+		l = strings.TrimLeft(l, Indent+" ")
+		if len(l) == 0 || strings.HasPrefix(l, "AVP [") {
+			continue
+		}
+		if strings.HasPrefix(l, "Flags") {
+			f := make(map[string]bool)
+			for _, t := range strings.Split(strings.TrimSpace(l[5:]), ",") {
+				kv := strings.SplitN(strings.TrimSpace(t), "=", 2)
+				if len(kv) == 2 {
+					f[kv[0]] = kv[1] == "true"
+				}
+			}
+			if a == nil {
+				m.FlgR, m.FlgP, m.FlgE, m.FlgT = f["R"], f["P"], f["E"], f["T"]
+			} else {
+				a.FlgV, a.FlgM, a.FlgP = f["V"], f["M"], f["P"]
+			}
+			continue
+		}
+
+		j := strings.IndexByte(l, '=')
+		if j < 0 {
+			return fmt.Errorf("line %d: invalid dump %s", i+1, l)
+		}
+		k, v := strings.TrimSpace(l[:j]), strings.TrimSpace(l[j+1:])
+		if k == "Data" {
+			if a == nil {
+				return fmt.Errorf("line %d: data without AVP", i+1)
+			}
+			b, e := hex.DecodeString(strings.ReplaceAll(v, " ", ""))
+			if e != nil {
+				return fmt.Errorf("line %d: invalid data %s", i+1, v)
+			}
+			a.data = b
+			continue
+		}
+		n, e := strconv.ParseUint(v, 10, 32)
+		if e != nil {
+			return fmt.Errorf("line %d: invalid value of %s", i+1, k)
+		}
+		switch k {
+		case "Version":
+			m.Ver = uint8(n)
+		case "Command-Code":
+			m.Code = uint32(n)
+		case "Application-ID":
+			m.AppID = uint32(n)
+		case "Hop-by-Hop ID":
+			m.HbHID = uint32(n)
+		case "End-to-End ID":
+			m.EtEID = uint32(n)
+		case "AVP Code":
+			m.AVP = append(m.AVP, RawAVP{Code: uint32(n), data: []byte{}})
+			a = &m.AVP[len(m.AVP)-1]
+		case "Vendor-ID":
+			if a == nil {
+				return fmt.Errorf("line %d: vendor without AVP", i+1)
+			}
+			a.VenID = uint32(n)
+		default:
+			return fmt.Errorf("line %d: unknown field %s", i+1, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package diameter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testTextMsg(t *testing.T) RawMsg {
+	return RawMsg{Ver: 1, FlgR: true, FlgP: true, FlgT: true, Code: 316, AppID: 16777251,
+		HbHID: 0x12345678, EtEID: 0x9abcdef0, AVP: []RawAVP{
+			SetSessionID("mme.example.com;1;2 # not comment"),
+			SetVendorSpecAppID(10415, 16777251),
+			SetAuthSessionState(false),
+			SetOriginHost("mme.example.com"),
+			testPathAVP(t, "Subscription-Data", []RawAVP{
+				testPathAVP(t, "Subscriber-Status", Enumerated(0)),
+				testPathAVP(t, "AMBR", []RawAVP{
+					testPathAVP(t, "Max-Requested-Bandwidth-UL", uint32(1000))}),
+				// empty Grouped AVP
+				testPathAVP(t, "APN-Configuration-Profile", []RawAVP{})}),
+			// flags that are different from dictionary
+			{Code: 264, FlgM: false, FlgP: true, data: []byte("h")},
+			// unknown AVP
+			{Code: 99999, VenID: 1, FlgV: true, data: []byte{1, 2, 3}},
+			{Code: 99998, FlgM: true, data: []byte{}},
+			// invalid value for the data type
+			{Code: 268, FlgM: true, data: []byte{0, 0, 7}},
+			{Code: 1, FlgM: true, data: []byte{0x61, 0xff}},
+			{Code: 260, FlgM: true, data: []byte{0, 0, 1}},
+			// OctetString
+			{Code: 25, FlgM: true, data: []byte("class")},
+		}}
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	m := testTextMsg(t)
+	w := new(bytes.Buffer)
+	m.WriteTo(w)
+
+	b, e := m.MarshalText()
+	if e != nil {
+		t.Fatalf("marshal failed: %s", e)
+	}
+	for name, text := range map[string]string{
+		"text format": string(b),
+		"dump":        m.String()} {
+		t.Run(name, func(t *testing.T) {
+			var m2 RawMsg
+			if e := m2.UnmarshalText([]byte(text)); e != nil {
+				t.Fatalf("unmarshal failed: %s\n%s", e, text)
+			}
+			w2 := new(bytes.Buffer)
+			m2.WriteTo(w2)
+			if !bytes.Equal(w.Bytes(), w2.Bytes()) {
+				t.Errorf("round trip changed message\n%s\n% x\n% x", text, w.Bytes(), w2.Bytes())
+			}
+		})
+	}
+
+	for _, sub := range []string{
+		"Update-Location-Request [RPT] hbh=0x12345678 ete=0x9abcdef0\n",
+		"  Subscriber-Status = SERVICE_GRANTED\n",
+		"  APN-Configuration-Profile {}\n",
+		"Origin-Host [P] = \"h\"\n",
+		"99999:1 = 0x010203\n",
+		"99998 [M] = 0x\n",
+		"Result-Code = 0x000007\n",
+	} {
+		if !strings.Contains(string(b), sub) {
+			t.Errorf("text does not contain %q\n%s", sub, b)
+		}
+	}
+}
+
+func TestTextTemplate(t *testing.T) {
+	var m RawMsg
+	e := m.UnmarshalText([]byte(`# template
+Update-Location-Request hbh=1 ete=0x2
+Session-Id = "s;1;2"   # comment
+Origin-Host = host.example.com
+Subscription-Data {
+  Subscriber-Status = "1"
+  AMBR { }
+}
+Result-Code = "2001"
+`))
+	if e != nil {
+		t.Fatalf("unmarshal failed: %s", e)
+	}
+	if !m.FlgR || m.Code != 316 || m.AppID != 16777251 || m.HbHID != 1 || m.EtEID != 2 {
+		t.Errorf("invalid header %+v", m)
+	}
+	for path, want := range map[string]string{
+		"Session-Id":                          "s;1;2",
+		"Origin-Host":                         "host.example.com",
+		"Subscription-Data/Subscriber-Status": "1",
+		"Result-Code":                         "2001",
+	} {
+		if s, e := m.GetString(path); e != nil || s != want {
+			t.Errorf("%s is %q (%v), want %q", path, s, e, want)
+		}
+	}
+	if l, _ := m.Select("Subscription-Data/AMBR"); len(l) != 1 || len(l[0].data) != 0 {
+		t.Errorf("empty Grouped AVP is not decoded")
+	}
+}
+
+func TestTextInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"no header", "# comment only\n"},
+		{"unknown command", "Unknown-Command-Request\n"},
+		{"no request or answer", "Update-Location\n"},
+		{"bad command flag", "Update-Location-Request [RX]\n"},
+		{"unknown header field", "Update-Location-Request foo=1\n"},
+		{"bad header value", "Update-Location-Request hbh=x\n"},
+		{"bad version", "316 ver=256\n"},
+		{"unclosed grouped", "316\nSubscription-Data {\n  Subscriber-Status = 0\n"},
+		{"unexpected close", "316\n}\n"},
+		{"unknown AVP", "316\nUnknown-AVP-Name = 1\n"},
+		{"bad AVP flag", "316\nOrigin-Host [X] = \"h\"\n"},
+		{"unclosed AVP flag", "316\nOrigin-Host [M = \"h\"\n"},
+		{"no value", "316\nOrigin-Host\n"},
+		{"invalid value", "316\nResult-Code = abc\n"},
+		{"invalid hex", "316\n99999 = 0x0g\n"},
+		{"unclosed quote", "316\nSession-Id = \"abc\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m RawMsg
+			if e := m.UnmarshalText([]byte(tt.text)); e == nil {
+				t.Errorf("decoded invalid text\n%s", tt.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package diameter
+
+import (
+	"fmt"
+)
+
+var (
+	// Tracer is tracer for Diameter transaction.
+	// Tracing is disabled when value is nil.
+	Tracer SpanTracer
+
+	// TracePropagation enables propagation of trace context
+	// in Trace-Context AVP.
+	// It is used only when TraceVendorID is set.
+	TracePropagation = false
+	// TraceVendorID is Vendor-ID of Trace-Context AVP.
+	// It must be set to vendor ID that is agreed with the peers,
+	// and Trace-Context AVP is not used when value is 0.
+	TraceVendorID uint32
+	// TraceAVPCode is AVP code of Trace-Context AVP
+	TraceAVPCode uint32 = 1
+)
+
+// SpanKind is kind of tracing span
+type SpanKind int
+
+const (
+	// SpanClient is span of outbound request that is sent by Send
+	SpanClient SpanKind = iota
+	// SpanServer is span of inbound request that is recieved by Recieve
+	SpanServer
+	// SpanRelay is span of outbound request that is relayed
+	// with trace context of inbound request
+	SpanRelay
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanClient:
+		return "client"
+	case SpanServer:
+		return "server"
+	case SpanRelay:
+		return "relay"
+	}
+	return "<nil>"
+}
+
+// SpanTracer starts span of Diameter transaction
+type SpanTracer interface {
+	// Start is called when the transaction is started.
+	// parent is trace context that is recieved in Trace-Context AVP,
+	// or nil when it is not recieved.
+	Start(name string, kind SpanKind, parent []byte, attrs map[string]interface{}) Span
+}
+
+// Span is tracing span of Diameter transaction
+type Span interface {
+	// Context returns trace context that is sent in Trace-Context AVP
+	Context() []byte
+	// End is called when the transaction is completed.
+	// err is not nil when answer is not recieved or not sent.
+	End(attrs map[string]interface{}, err error)
+}
+
+// SetTraceContext make Trace-Context AVP
+func SetTraceContext(v []byte) (a RawAVP) {
+	a = RawAVP{Code: TraceAVPCode, VenID: TraceVendorID,
+		FlgV: true, FlgM: false, FlgP: false}
+	a.Encode(v)
+	return
+}
+
+// GetTraceContext read Trace-Context AVP
+func GetTraceContext(a RawAVP) (v []byte, e error) {
+	if !a.FlgV || a.FlgM || a.FlgP {
+		e = InvalidAVP(DiameterInvalidAvpBits)
+	} else {
+		e = a.Decode(&v)
+	}
+	return
+}
+
+// getTraceContext returns trace context in the message
+func getTraceContext(m RawMsg) []byte {
+	if TraceVendorID == 0 {
+		return nil
+	}
+	for _, a := range m.AVP {
+		if a.Code == TraceAVPCode && a.VenID == TraceVendorID {
+			if v, e := GetTraceContext(a); e == nil {
+				return v
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// setTraceContext replace trace context in the message
+func setTraceContext(m *RawMsg, v []byte) {
+	avp := make([]RawAVP, 0, len(m.AVP)+1)
+	for _, a := range m.AVP {
+		if a.Code != TraceAVPCode || a.VenID != TraceVendorID {
+			avp = append(avp, a)
+		}
+	}
+	if len(v) != 0 {
+		avp = append(avp, SetTraceContext(v))
+	}
+	m.AVP = avp
+}
+
+func traceAttrs(c *Conn, m RawMsg) map[string]interface{} {
+	r := map[string]interface{}{
+		"diameter.cmd": m.Code,
+		"diameter.app": m.AppID,
+		"diameter.hbh": m.HbHID,
+		"diameter.ete": m.EtEID}
+	if c != nil && c.Peer != nil {
+		r["diameter.peer.host"] = string(c.Peer.Host)
+		r["diameter.peer.realm"] = string(c.Peer.Realm)
+	}
+	return r
+}
+
+// startSpan start span of the request.
+// Trace context in the request is replaced with the span
+// when TracePropagation is enabled.
+func startSpan(c *Conn, kind SpanKind, m *RawMsg) Span {
+	if Tracer == nil {
+		return nil
+	}
+	parent := getTraceContext(*m)
+	if kind == SpanClient && parent != nil {
+		kind = SpanRelay
+	}
+	s := Tracer.Start(fmt.Sprintf("diameter %d/%d", m.AppID, m.Code),
+		kind, parent, traceAttrs(c, *m))
+	if s == nil {
+		return nil
+	}
+	if TracePropagation && TraceVendorID != 0 {
+		// trace context of inbound request is replaced too,
+		// then relayed request becomes child of the inbound request.
+		setTraceContext(m, s.Context())
+	}
+	return s
+}
+
+// endSpan end span with the answer
+func endSpan(s Span, m RawMsg, e error) {
+	if s == nil {
+		return
+	}
+	attrs := map[string]interface{}{}
+	if m.Code != 0 {
+		attrs["diameter.result"] = getResult(m)
+	}
+	s.End(attrs, e)
+}
@@ -13,7 +13,7 @@ import (
 ALR is AlertServiceCentreRequest message.
  <ALR> ::= < Diameter Header: 8388648, REQ, PXY, 16777312 >
 		   < Session-Id >
-		   [ DRMP ] // not supported
+		   [ DRMP ]
 		   [ Vendor-Specific-Application-Id ]
 		   { Auth-Session-State }
 		   { Origin-Host }
@@ -32,6 +32,7 @@ ALR is AlertServiceCentreRequest message.
 		 * [ Route-Record ]
 */
 type ALR struct {
+	dia.DRMP
 	OriginHost       dia.Identity
 	OriginRealm      dia.Identity
 	DestinationHost  dia.Identity
@@ -46,11 +47,16 @@ type ALR struct {
 		AvailForMT   bool
 		UnderNewNode bool
 	}
+
+	AVP []dia.RawAVP
 }
 
 func (v ALR) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != dia.UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP              =%s\n", dia.Indent, v.DRMP)
+	}
 	fmt.Fprintf(w, "%sOrigin-Host       =%s\n", dia.Indent, v.OriginHost)
 	fmt.Fprintf(w, "%sOrigin-Realm      =%s\n", dia.Indent, v.OriginRealm)
 	fmt.Fprintf(w, "%sDestination-Host  =%s\n", dia.Indent, v.DestinationHost)
@@ -76,6 +82,9 @@ func (v ALR) ToRaw(s string) dia.RawMsg {
 		AVP: make([]dia.RawAVP, 0, 15)}
 
 	m.AVP = append(m.AVP, dia.SetSessionID(s))
+	if v.DRMP != dia.UnknownPriority {
+		m.AVP = append(m.AVP, dia.SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, dia.SetVendorSpecAppID(10415, m.AppID))
 	m.AVP = append(m.AVP, dia.SetAuthSessionState(false))
 
@@ -96,6 +105,7 @@ func (v ALR) ToRaw(s string) dia.RawMsg {
 			v.Flags.AvailForMT, v.Flags.UnderNewNode))
 	}
 
+	m.AVP = append(m.AVP, v.AVP...)
 	m.AVP = append(m.AVP, dia.SetRouteRecord(v.OriginHost))
 	return m
 }
@@ -113,6 +123,8 @@ func (ALR) FromRaw(m dia.RawMsg) (dia.Request, string, error) {
 		switch a.Code {
 		case 263:
 			s, e = dia.GetSessionID(a)
+		case 301:
+			v.DRMP, e = dia.GetDRMP(a)
 		case 264:
 			v.OriginHost, e = dia.GetOriginHost(a)
 		case 296:
@@ -130,6 +142,9 @@ func (ALR) FromRaw(m dia.RawMsg) (dia.Request, string, error) {
 			v.AvailTime, e = getMaximumUEAvailabilityTime(a)
 		case 3333:
 			v.Flags.AvailForMT, v.Flags.UnderNewNode, e = getSMSGMSCAlertEvent(a)
+		case 260, 277, 282:
+		default:
+			v.AVP = append(v.AVP, a)
 		}
 
 		if e != nil {
@@ -149,6 +164,7 @@ func (ALR) FromRaw(m dia.RawMsg) (dia.Request, string, error) {
 // Failed make error message for timeout
 func (v ALR) Failed(c uint32) dia.Answer {
 	return ALA{
+		DRMP:        v.DRMP,
 		ResultCode:  c,
 		OriginHost:  dia.Host,
 		OriginRealm: dia.Realm}
@@ -158,7 +174,7 @@ func (v ALR) Failed(c uint32) dia.Answer {
 ALA is AlertServiceCentreAnswer message.
  <ALA> ::= < Diameter Header: 8388648, PXY, 16777312 >
 		   < Session-Id >
-		   [ DRMP ] // not supported
+		   [ DRMP ]
 		   [ Vendor-Specific-Application-Id ]
 		   [ Result-Code ]
 		   [ Experimental-Result ]
@@ -172,16 +188,22 @@ ALA is AlertServiceCentreAnswer message.
 		 * [ Route-Record ]
 */
 type ALA struct {
+	dia.DRMP
 	ResultCode  uint32
 	OriginHost  dia.Identity
 	OriginRealm dia.Identity
 
 	FailedAVP []dia.RawAVP
+
+	AVP []dia.RawAVP
 }
 
 func (v ALA) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != dia.UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP              =%s\n", dia.Indent, v.DRMP)
+	}
 	if v.ResultCode > 10000 {
 		fmt.Fprintf(w, "%sExp-Result-Code   =%d:%d\n", dia.Indent, v.ResultCode/10000, v.ResultCode%10000)
 	} else {
@@ -201,12 +223,16 @@ func (v ALA) ToRaw(s string) dia.RawMsg {
 		Code: 8388648, AppID: 16777312,
 		AVP: make([]dia.RawAVP, 0, 10)}
 
-	m.AVP = append(m.AVP, dia.SetResultCode(v.ResultCode))
 	m.AVP = append(m.AVP, dia.SetSessionID(s))
+	m.AVP = append(m.AVP, dia.SetResultCode(v.ResultCode))
+	if v.DRMP != dia.UnknownPriority {
+		m.AVP = append(m.AVP, dia.SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, dia.SetVendorSpecAppID(10415, m.AppID))
 	m.AVP = append(m.AVP, dia.SetAuthSessionState(false))
 	m.AVP = append(m.AVP, dia.SetOriginHost(v.OriginHost))
 	m.AVP = append(m.AVP, dia.SetOriginRealm(v.OriginRealm))
+	m.AVP = append(m.AVP, v.AVP...)
 
 	if v.ResultCode != dia.DiameterSuccess && len(v.FailedAVP) != 0 {
 		m.AVP = append(m.AVP, dia.SetFailedAVP(v.FailedAVP))
@@ -227,12 +253,19 @@ func (ALA) FromRaw(m dia.RawMsg) (dia.Answer, string, error) {
 		switch a.Code {
 		case 263:
 			s, e = dia.GetSessionID(a)
+		case 301:
+			v.DRMP, e = dia.GetDRMP(a)
 		case 268, 297:
 			v.ResultCode, e = dia.GetResultCode(a)
 		case 264:
 			v.OriginHost, e = dia.GetOriginHost(a)
 		case 296:
 			v.OriginRealm, e = dia.GetOriginRealm(a)
+		case 279:
+			v.FailedAVP, e = dia.GetFailedAVP(a)
+		case 260, 277, 282:
+		default:
+			v.AVP = append(v.AVP, a)
 		}
 		if e != nil {
 			return nil, s, e
@@ -13,7 +13,7 @@ import (
 RDR is Report-SM-Delivery-Status-Request message.
  <RDR> ::= < Diameter Header: 8388649, REQ, PXY, 16777312 >
 		   < Session-Id >
-		   [ DRMP ] // not supported
+		   [ DRMP ]
 		   [ Vendor-Specific-Application-Id ]
 		   { Auth-Session-State }
 		   { Origin-Host }
@@ -31,6 +31,7 @@ RDR is Report-SM-Delivery-Status-Request message.
 		 * [ Route-Record ]
 */
 type RDR struct {
+	dia.DRMP
 	OriginHost       dia.Identity
 	OriginRealm      dia.Identity
 	DestinationHost  dia.Identity
@@ -58,15 +59,19 @@ type RDR struct {
 		SingleAttempt bool
 	}
 
-	// DRMP
 	// SMSMICorrelationID
 	// []SupportedFeatures
 	// []ProxyInfo
+
+	AVP []dia.RawAVP
 }
 
 func (v RDR) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != dia.UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP              =%s\n", dia.Indent, v.DRMP)
+	}
 	fmt.Fprintf(w, "%sOrigin-Host       =%s\n", dia.Indent, v.OriginHost)
 	fmt.Fprintf(w, "%sOrigin-Realm      =%s\n", dia.Indent, v.OriginRealm)
 	fmt.Fprintf(w, "%sDestination-Host  =%s\n", dia.Indent, v.DestinationHost)
@@ -121,6 +126,9 @@ func (v RDR) ToRaw(s string) dia.RawMsg {
 		AVP: make([]dia.RawAVP, 0, 15)}
 
 	m.AVP = append(m.AVP, dia.SetSessionID(s))
+	if v.DRMP != dia.UnknownPriority {
+		m.AVP = append(m.AVP, dia.SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, dia.SetVendorSpecAppID(10415, m.AppID))
 	m.AVP = append(m.AVP, dia.SetAuthSessionState(false))
 
@@ -146,6 +154,7 @@ func (v RDR) ToRaw(s string) dia.RawMsg {
 		m.AVP = append(m.AVP, setRDRFlags(v.Flags.SingleAttempt))
 	}
 
+	m.AVP = append(m.AVP, v.AVP...)
 	m.AVP = append(m.AVP, dia.SetRouteRecord(v.OriginHost))
 	return m
 }
@@ -163,6 +172,8 @@ func (RDR) FromRaw(m dia.RawMsg) (dia.Request, string, error) {
 		switch a.Code {
 		case 263:
 			s, e = dia.GetSessionID(a)
+		case 301:
+			v.DRMP, e = dia.GetDRMP(a)
 		case 264:
 			v.OriginHost, e = dia.GetOriginHost(a)
 		case 296:
@@ -186,6 +197,9 @@ func (RDR) FromRaw(m dia.RawMsg) (dia.Request, string, error) {
 				e = getSMDeliveryOutcome(a)
 		case 3323:
 			v.Flags.SingleAttempt, e = getRDRFlags(a)
+		case 260, 277, 282:
+		default:
+			v.AVP = append(v.AVP, a)
 		}
 
 		if e != nil {
@@ -209,6 +223,7 @@ func (RDR) FromRaw(m dia.RawMsg) (dia.Request, string, error) {
 // Failed make error message for timeout
 func (v RDR) Failed(c uint32) dia.Answer {
 	return SRA{
+		DRMP:        v.DRMP,
 		ResultCode:  c,
 		OriginHost:  dia.Host,
 		OriginRealm: dia.Realm}
@@ -218,7 +233,7 @@ func (v RDR) Failed(c uint32) dia.Answer {
 RDA is ReportSMDeliveryStatusAnswer message.
  <RDA> ::= < Diameter Header: 8388649, PXY, 16777312 >
 		   < Session-Id >
-		   [ DRMP ] // not supported
+		   [ DRMP ]
 		   [ Vendor-Specific-Application-Id ]
 		   [ Result-Code ]
 		   [ Experimental-Result ]
@@ -233,7 +248,7 @@ RDA is ReportSMDeliveryStatusAnswer message.
 		 * [ Route-Record ]
 */
 type RDA struct {
-	// DRMP
+	dia.DRMP
 	ResultCode  uint32
 	OriginHost  dia.Identity
 	OriginRealm dia.Identity
@@ -241,11 +256,16 @@ type RDA struct {
 	MSISDN teldata.E164
 
 	FailedAVP []dia.RawAVP
+
+	AVP []dia.RawAVP
 }
 
 func (v RDA) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != dia.UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP              =%s\n", dia.Indent, v.DRMP)
+	}
 	if v.ResultCode > 10000 {
 		fmt.Fprintf(w, "%sExp-Result-Code   =%d:%d\n", dia.Indent, v.ResultCode/10000, v.ResultCode%10000)
 	} else {
@@ -269,12 +289,16 @@ func (v RDA) ToRaw(s string) dia.RawMsg {
 		Code: 8388649, AppID: 16777312,
 		AVP: make([]dia.RawAVP, 0, 20)}
 
-	m.AVP = append(m.AVP, dia.SetResultCode(v.ResultCode))
 	m.AVP = append(m.AVP, dia.SetSessionID(s))
+	m.AVP = append(m.AVP, dia.SetResultCode(v.ResultCode))
+	if v.DRMP != dia.UnknownPriority {
+		m.AVP = append(m.AVP, dia.SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, dia.SetVendorSpecAppID(10415, m.AppID))
 	m.AVP = append(m.AVP, dia.SetAuthSessionState(false))
 	m.AVP = append(m.AVP, dia.SetOriginHost(v.OriginHost))
 	m.AVP = append(m.AVP, dia.SetOriginRealm(v.OriginRealm))
+	m.AVP = append(m.AVP, v.AVP...)
 
 	if v.ResultCode != dia.DiameterSuccess {
 		if len(v.FailedAVP) != 0 {
@@ -301,6 +325,8 @@ func (RDA) FromRaw(m dia.RawMsg) (dia.Answer, string, error) {
 		switch a.Code {
 		case 263:
 			s, e = dia.GetSessionID(a)
+		case 301:
+			v.DRMP, e = dia.GetDRMP(a)
 		case 268, 297:
 			v.ResultCode, e = dia.GetResultCode(a)
 		case 264:
@@ -310,6 +336,11 @@ func (RDA) FromRaw(m dia.RawMsg) (dia.Answer, string, error) {
 
 		case 3102:
 			_, v.MSISDN, e = getUserIdentifier(a)
+		case 279:
+			v.FailedAVP, e = dia.GetFailedAVP(a)
+		case 260, 277, 282:
+		default:
+			v.AVP = append(v.AVP, a)
 		}
 		if e != nil {
 			return nil, s, e
@@ -13,7 +13,7 @@ import (
 SRR is Send-Routing-Info-For-SM-Request message.
  <SRR> ::= < Diameter Header: 8388647, REQ, PXY, 16777312 >
            < Session-Id >
-		   [ DRMP ]  // not supported
+		   [ DRMP ]
            [ Vendor-Specific-Application-Id ]
            { Auth-Session-State }
            { Origin-Host }
@@ -35,7 +35,7 @@ SRR is Send-Routing-Info-For-SM-Request message.
 IP-SM-GW and MSISDN-less SMS are not supported.
 */
 type SRR struct {
-	// DRMP
+	dia.DRMP
 	OriginHost       dia.Identity
 	OriginRealm      dia.Identity
 	DestinationHost  dia.Identity
@@ -57,11 +57,16 @@ type SRR struct {
 	// SMSMICorrelationID
 	// []SupportedFeatures
 	// []ProxyInfo
+
+	AVP []dia.RawAVP
 }
 
 func (v SRR) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != dia.UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP              =%s\n", dia.Indent, v.DRMP)
+	}
 	fmt.Fprintf(w, "%sOrigin-Host       =%s\n", dia.Indent, v.OriginHost)
 	fmt.Fprintf(w, "%sOrigin-Realm      =%s\n", dia.Indent, v.OriginRealm)
 	if len(v.DestinationHost) != 0 {
@@ -111,6 +116,9 @@ func (v SRR) ToRaw(s string) dia.RawMsg {
 		AVP: make([]dia.RawAVP, 0, 15)}
 
 	m.AVP = append(m.AVP, dia.SetSessionID(s))
+	if v.DRMP != dia.UnknownPriority {
+		m.AVP = append(m.AVP, dia.SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, dia.SetVendorSpecAppID(10415, m.AppID))
 	m.AVP = append(m.AVP, dia.SetAuthSessionState(false))
 
@@ -144,6 +152,7 @@ func (v SRR) ToRaw(s string) dia.RawMsg {
 		m.AVP = append(m.AVP, setSMDeliveryNotIntended(v.RequiredInfo))
 	}
 
+	m.AVP = append(m.AVP, v.AVP...)
 	m.AVP = append(m.AVP, dia.SetRouteRecord(v.OriginHost))
 	return m
 }
@@ -161,6 +170,8 @@ func (SRR) FromRaw(m dia.RawMsg) (dia.Request, string, error) {
 		switch a.Code {
 		case 263:
 			s, e = dia.GetSessionID(a)
+		case 301:
+			v.DRMP, e = dia.GetDRMP(a)
 		case 264:
 			v.OriginHost, e = dia.GetOriginHost(a)
 		case 296:
@@ -184,6 +195,9 @@ func (SRR) FromRaw(m dia.RawMsg) (dia.Request, string, error) {
 			v.Flags.GPRSSupport, v.Flags.SingleAttempt, v.Flags.Prioritized, e = getSRRFlags(a)
 		case 3311:
 			v.RequiredInfo, e = getSMDeliveryNotIntended(a)
+		case 260, 277, 282:
+		default:
+			v.AVP = append(v.AVP, a)
 		}
 
 		if e != nil {
@@ -203,6 +217,7 @@ func (SRR) FromRaw(m dia.RawMsg) (dia.Request, string, error) {
 // Failed make error message for timeout
 func (v SRR) Failed(c uint32) dia.Answer {
 	return SRA{
+		DRMP:        v.DRMP,
 		ResultCode:  c,
 		OriginHost:  dia.Host,
 		OriginRealm: dia.Realm}
@@ -212,7 +227,7 @@ func (v SRR) Failed(c uint32) dia.Answer {
 SRA is SendRoutingInfoForSMAnswer message.
  <SRA> ::= < Diameter Header: 8388647, PXY, 16777312 >
            < Session-Id >
-		   [ DRMP ] // not supported
+		   [ DRMP ]
            [ Vendor-Specific-Application-Id ]
            [ Result-Code ]
            [ Experimental-Result ]
@@ -236,7 +251,7 @@ SRA is SendRoutingInfoForSMAnswer message.
 IP-SM-GW and MSISDN-less SMS are not supported.
 */
 type SRA struct {
-	// DRMP
+	dia.DRMP
 	ResultCode  uint32
 	OriginHost  dia.Identity
 	OriginRealm dia.Identity
@@ -267,11 +282,16 @@ type SRA struct {
 	FailedAVP []dia.RawAVP
 	// []SupportedFeatures
 	// []ProxyInfo
+
+	AVP []dia.RawAVP
 }
 
 func (v SRA) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != dia.UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP              =%s\n", dia.Indent, v.DRMP)
+	}
 	if v.ResultCode > 10000 {
 		fmt.Fprintf(w, "%sExp-Result-Code   =%d:%d\n", dia.Indent, v.ResultCode/10000, v.ResultCode%10000)
 	} else {
@@ -325,12 +345,16 @@ func (v SRA) ToRaw(s string) dia.RawMsg {
 		Code: 8388647, AppID: 16777312,
 		AVP: make([]dia.RawAVP, 0, 20)}
 
-	m.AVP = append(m.AVP, dia.SetResultCode(v.ResultCode))
 	m.AVP = append(m.AVP, dia.SetSessionID(s))
+	m.AVP = append(m.AVP, dia.SetResultCode(v.ResultCode))
+	if v.DRMP != dia.UnknownPriority {
+		m.AVP = append(m.AVP, dia.SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, dia.SetVendorSpecAppID(10415, m.AppID))
 	m.AVP = append(m.AVP, dia.SetAuthSessionState(false))
 	m.AVP = append(m.AVP, dia.SetOriginHost(v.OriginHost))
 	m.AVP = append(m.AVP, dia.SetOriginRealm(v.OriginRealm))
+	m.AVP = append(m.AVP, v.AVP...)
 
 	if v.ResultCode != dia.DiameterSuccess {
 		if len(v.FailedAVP) != 0 {
@@ -396,6 +420,8 @@ func (SRA) FromRaw(m dia.RawMsg) (dia.Answer, string, error) {
 		switch a.Code {
 		case 263:
 			s, e = dia.GetSessionID(a)
+		case 301:
+			v.DRMP, e = dia.GetDRMP(a)
 		case 268, 297:
 			v.ResultCode, e = dia.GetResultCode(a)
 		case 264:
@@ -424,6 +450,11 @@ func (SRA) FromRaw(m dia.RawMsg) (dia.Answer, string, error) {
 			v.AbsentUserDiag.MSC, e = getMSCAbsentUserDiagnosticSM(a)
 		case 3315:
 			v.AbsentUserDiag.SGSN, e = getSGSNAbsentUserDiagnosticSM(a)
+		case 279:
+			v.FailedAVP, e = dia.GetFailedAVP(a)
+		case 260, 277, 282:
+		default:
+			v.AVP = append(v.AVP, a)
 		}
 		if e != nil {
 			return nil, s, e
@@ -14,7 +14,7 @@ import (
 TFR is MT-Forward-Short-Message-Request message.
  <TFR> ::= < Diameter Header: 8388646, REQ, PXY, 16777313 >
            < Session-Id >
-           [ DRMP ]  // not supported
+           [ DRMP ]
            [ Vendor-Specific-Application-Id ]
            { Auth-Session-State }
            { Origin-Host }
@@ -38,6 +38,7 @@ TFR is MT-Forward-Short-Message-Request message.
          * [ Route-Record ]
 */
 type TFR struct {
+	dia.DRMP
 	OriginHost       dia.Identity
 	OriginRealm      dia.Identity
 	DestinationHost  dia.Identity
@@ -57,11 +58,16 @@ type TFR struct {
 	DeliveryStartTime time.Time
 	MaxRetransTime    time.Time
 	SMSGMSCAddress    teldata.E164
+
+	AVP []dia.RawAVP
 }
 
 func (v TFR) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != dia.UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP              =%s\n", dia.Indent, v.DRMP)
+	}
 	fmt.Fprintf(w, "%sOrigin-Host       =%s\n", dia.Indent, v.OriginHost)
 	fmt.Fprintf(w, "%sOrigin-Realm      =%s\n", dia.Indent, v.OriginRealm)
 	fmt.Fprintf(w, "%sDestination-Host  =%s\n", dia.Indent, v.DestinationHost)
@@ -100,6 +106,9 @@ func (v TFR) ToRaw(s string) dia.RawMsg {
 		AVP: make([]dia.RawAVP, 0, 15)}
 
 	m.AVP = append(m.AVP, dia.SetSessionID(s))
+	if v.DRMP != dia.UnknownPriority {
+		m.AVP = append(m.AVP, dia.SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, dia.SetVendorSpecAppID(10415, m.AppID))
 	m.AVP = append(m.AVP, dia.SetAuthSessionState(false))
 
@@ -136,6 +145,7 @@ func (v TFR) ToRaw(s string) dia.RawMsg {
 		m.AVP = append(m.AVP, setSMSGMSCAddress(v.SMSGMSCAddress))
 	}
 
+	m.AVP = append(m.AVP, v.AVP...)
 	m.AVP = append(m.AVP, dia.SetRouteRecord(v.OriginHost))
 	return m
 }
@@ -153,6 +163,8 @@ func (TFR) FromRaw(m dia.RawMsg) (dia.Request, string, error) {
 		switch a.Code {
 		case 263:
 			s, e = dia.GetSessionID(a)
+		case 301:
+			v.DRMP, e = dia.GetDRMP(a)
 		case 264:
 			v.OriginHost, e = dia.GetOriginHost(a)
 		case 296:
@@ -182,6 +194,9 @@ func (TFR) FromRaw(m dia.RawMsg) (dia.Request, string, error) {
 			v.MaxRetransTime, e = getMaximumRetransmissionTime(a)
 		case 3332:
 			v.SMSGMSCAddress, e = getSMSGMSCAddress(a)
+		case 260, 277, 282:
+		default:
+			v.AVP = append(v.AVP, a)
 		}
 
 		if e != nil {
@@ -205,6 +220,7 @@ func (TFR) FromRaw(m dia.RawMsg) (dia.Request, string, error) {
 // Failed make error message for timeout
 func (v TFR) Failed(c uint32) dia.Answer {
 	return TFA{
+		DRMP:        v.DRMP,
 		ResultCode:  c,
 		OriginHost:  dia.Host,
 		OriginRealm: dia.Realm}
@@ -214,7 +230,7 @@ func (v TFR) Failed(c uint32) dia.Answer {
 TFA is MT-Forward-Short-Message-Answer message.
  <TFA> ::= < Diameter Header: 8388646, PXY, 16777313 >
            < Session-Id >
-           [ DRMP ] // not supported
+           [ DRMP ]
            [ Vendor-Specific-Application-Id ]
            [ Result-Code ]
            [ Experimental-Result ]
@@ -233,6 +249,7 @@ TFA is MT-Forward-Short-Message-Answer message.
          * [ Route-Record ]
 */
 type TFA struct {
+	dia.DRMP
 	ResultCode  uint32
 	OriginHost  dia.Identity
 	OriginRealm dia.Identity
@@ -244,11 +261,16 @@ type TFA struct {
 	ReqRetransTime time.Time
 
 	FailedAVP []dia.RawAVP
+
+	AVP []dia.RawAVP
 }
 
 func (v TFA) String() string {
 	w := new(bytes.Buffer)
 
+	if v.DRMP != dia.UnknownPriority {
+		fmt.Fprintf(w, "%sDRMP              =%s\n", dia.Indent, v.DRMP)
+	}
 	if v.ResultCode > 10000 {
 		fmt.Fprintf(w, "%sExp-Result-Code   =%d:%d\n", dia.Indent, v.ResultCode/10000, v.ResultCode%10000)
 	} else {
@@ -297,13 +319,17 @@ func (v TFA) ToRaw(s string) dia.RawMsg {
 		Code: 8388646, AppID: 16777313,
 		AVP: make([]dia.RawAVP, 0, 20)}
 
-	m.AVP = append(m.AVP, dia.SetResultCode(v.ResultCode))
 	m.AVP = append(m.AVP, dia.SetSessionID(s))
+	m.AVP = append(m.AVP, dia.SetResultCode(v.ResultCode))
+	if v.DRMP != dia.UnknownPriority {
+		m.AVP = append(m.AVP, dia.SetDRMP(v.DRMP))
+	}
 	m.AVP = append(m.AVP, dia.SetVendorSpecAppID(10415, m.AppID))
 
 	m.AVP = append(m.AVP, dia.SetAuthSessionState(false))
 	m.AVP = append(m.AVP, dia.SetOriginHost(v.OriginHost))
 	m.AVP = append(m.AVP, dia.SetOriginRealm(v.OriginRealm))
+	m.AVP = append(m.AVP, v.AVP...)
 
 	switch v.ResultCode {
 	case dia.DiameterSuccess:
@@ -333,12 +359,19 @@ func (TFA) FromRaw(m dia.RawMsg) (dia.Answer, string, error) {
 		switch a.Code {
 		case 263:
 			s, e = dia.GetSessionID(a)
+		case 301:
+			v.DRMP, e = dia.GetDRMP(a)
 		case 268, 297:
 			v.ResultCode, e = dia.GetResultCode(a)
 		case 264:
 			v.OriginHost, e = dia.GetOriginHost(a)
 		case 296:
 			v.OriginRealm, e = dia.GetOriginRealm(a)
+		case 279:
+			v.FailedAVP, e = dia.GetFailedAVP(a)
+		case 260, 277, 282, 3301, 3303, 3322, 3331:
+		default:
+			v.AVP = append(v.AVP, a)
 		}
 		if e != nil {
 			return nil, s, e
@@ -0,0 +1,55 @@
+package ts29338
+
+import (
+	"testing"
+	"time"
+
+	dia "github.com/fkgi/diameter"
+	"github.com/fkgi/sms"
+	"github.com/fkgi/teldata"
+)
+
+func TestMessageGrammar(t *testing.T) {
+	msisdn, _ := teldata.ParseE164("819012345678")
+	sc, _ := teldata.ParseE164("819000000000")
+	imsi, _ := teldata.ParseIMSI("440101234567890")
+	oh, or := dia.Identity("smsc.example.com"), dia.Identity("example.com")
+	dh, dr := dia.Identity("hss.example.com"), dia.Identity("example.net")
+
+	tfr := TFR{OriginHost: oh, OriginRealm: or,
+		DestinationHost: dh, DestinationRealm: dr,
+		IMSI: imsi, SCAddress: sc, MMEAddress: msisdn,
+		DeliveryStartTime: time.Now()}
+	tfr.SMSPDU.OA = sms.Address{TON: 1, NPI: 1, Addr: []byte{0x12, 0x34}}
+
+	tests := []struct {
+		name string
+		msg  interface{ ToRaw(string) dia.RawMsg }
+	}{
+		{"ALR", ALR{OriginHost: oh, OriginRealm: or, DestinationRealm: dr,
+			MSISDN: msisdn, SCAddress: sc}},
+		{"ALA", ALA{ResultCode: dia.DiameterSuccess, OriginHost: oh, OriginRealm: or}},
+		{"RDR", RDR{OriginHost: oh, OriginRealm: or, DestinationRealm: dr,
+			MSISDN: msisdn, IMSI: imsi, SCAddress: sc}},
+		{"RDA", RDA{ResultCode: dia.DiameterSuccess, OriginHost: oh, OriginRealm: or}},
+		{"SRR", SRR{OriginHost: oh, OriginRealm: or, DestinationRealm: dr,
+			MSISDN: msisdn, SCAddress: sc}},
+		{"SRA", SRA{ResultCode: dia.DiameterSuccess, OriginHost: oh, OriginRealm: or,
+			IMSI: imsi}},
+		{"TFR", tfr},
+		{"TFA", TFA{ResultCode: dia.DiameterSuccess, OriginHost: oh, OriginRealm: or}},
+		{"TFA with error", TFA{ResultCode: dia.DiameterUnableToComply,
+			OriginHost: oh, OriginRealm: or}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.msg.ToRaw("smsc.example.com;1;1")
+			if _, ok := dia.CommandGrammar(m.AppID, m.Code, m.FlgR); !ok {
+				t.Fatalf("no grammar of the command in dictionary")
+			}
+			if e := dia.CheckGrammar(m); e != nil {
+				t.Errorf("grammar error: %s", e)
+			}
+		})
+	}
+}
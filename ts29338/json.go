@@ -0,0 +1,107 @@
+package ts29338
+
+import dia "github.com/fkgi/diameter"
+
+// MarshalJSON returns JSON of the message in format of dia.RawMsg
+// with empty Session-Id. Use dia.MarshalMessage to set Session-Id.
+func (v SRR) MarshalJSON() ([]byte, error) { return dia.MarshalMessage(v, "") }
+
+// UnmarshalJSON decodes JSON in format of dia.RawMsg
+func (v *SRR) UnmarshalJSON(b []byte) error {
+	r, _, e := dia.UnmarshalRequest(b, SRR{})
+	if e == nil {
+		*v = r.(SRR)
+	}
+	return e
+}
+
+// MarshalJSON returns JSON of the message in format of dia.RawMsg
+// with empty Session-Id. Use dia.MarshalMessage to set Session-Id.
+func (v SRA) MarshalJSON() ([]byte, error) { return dia.MarshalMessage(v, "") }
+
+// UnmarshalJSON decodes JSON in format of dia.RawMsg
+func (v *SRA) UnmarshalJSON(b []byte) error {
+	r, _, e := dia.UnmarshalAnswer(b, SRA{})
+	if e == nil {
+		*v = r.(SRA)
+	}
+	return e
+}
+
+// MarshalJSON returns JSON of the message in format of dia.RawMsg
+// with empty Session-Id. Use dia.MarshalMessage to set Session-Id.
+func (v ALR) MarshalJSON() ([]byte, error) { return dia.MarshalMessage(v, "") }
+
+// UnmarshalJSON decodes JSON in format of dia.RawMsg
+func (v *ALR) UnmarshalJSON(b []byte) error {
+	r, _, e := dia.UnmarshalRequest(b, ALR{})
+	if e == nil {
+		*v = r.(ALR)
+	}
+	return e
+}
+
+// MarshalJSON returns JSON of the message in format of dia.RawMsg
+// with empty Session-Id. Use dia.MarshalMessage to set Session-Id.
+func (v ALA) MarshalJSON() ([]byte, error) { return dia.MarshalMessage(v, "") }
+
+// UnmarshalJSON decodes JSON in format of dia.RawMsg
+func (v *ALA) UnmarshalJSON(b []byte) error {
+	r, _, e := dia.UnmarshalAnswer(b, ALA{})
+	if e == nil {
+		*v = r.(ALA)
+	}
+	return e
+}
+
+// MarshalJSON returns JSON of the message in format of dia.RawMsg
+// with empty Session-Id. Use dia.MarshalMessage to set Session-Id.
+func (v RDR) MarshalJSON() ([]byte, error) { return dia.MarshalMessage(v, "") }
+
+// UnmarshalJSON decodes JSON in format of dia.RawMsg
+func (v *RDR) UnmarshalJSON(b []byte) error {
+	r, _, e := dia.UnmarshalRequest(b, RDR{})
+	if e == nil {
+		*v = r.(RDR)
+	}
+	return e
+}
+
+// MarshalJSON returns JSON of the message in format of dia.RawMsg
+// with empty Session-Id. Use dia.MarshalMessage to set Session-Id.
+func (v RDA) MarshalJSON() ([]byte, error) { return dia.MarshalMessage(v, "") }
+
+// UnmarshalJSON decodes JSON in format of dia.RawMsg
+func (v *RDA) UnmarshalJSON(b []byte) error {
+	r, _, e := dia.UnmarshalAnswer(b, RDA{})
+	if e == nil {
+		*v = r.(RDA)
+	}
+	return e
+}
+
+// MarshalJSON returns JSON of the message in format of dia.RawMsg
+// with empty Session-Id. Use dia.MarshalMessage to set Session-Id.
+func (v TFR) MarshalJSON() ([]byte, error) { return dia.MarshalMessage(v, "") }
+
+// UnmarshalJSON decodes JSON in format of dia.RawMsg
+func (v *TFR) UnmarshalJSON(b []byte) error {
+	r, _, e := dia.UnmarshalRequest(b, TFR{})
+	if e == nil {
+		*v = r.(TFR)
+	}
+	return e
+}
+
+// MarshalJSON returns JSON of the message in format of dia.RawMsg
+// with empty Session-Id. Use dia.MarshalMessage to set Session-Id.
+func (v TFA) MarshalJSON() ([]byte, error) { return dia.MarshalMessage(v, "") }
+
+// UnmarshalJSON decodes JSON in format of dia.RawMsg
+func (v *TFA) UnmarshalJSON(b []byte) error {
+	r, _, e := dia.UnmarshalAnswer(b, TFA{})
+	if e == nil {
+		*v = r.(TFA)
+	}
+	return e
+}
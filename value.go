@@ -0,0 +1,174 @@
+package diameter
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+ValueString returns text of the AVP value with data type in dictionary.
+Text of each type is
+
+	Integer32, Integer64, Unsigned32, Unsigned64, Enumerated: decimal number
+	Float32, Float64: decimal number of strconv 'g' format
+	OctetString: hex digits
+	UTF8String, DiameterIdentity, DiameterURI,
+	IPFilterRule, QoSFilterRule: string
+	Address: IP address, or family number and hex digits as "8:3831..."
+	Time: RFC 3339 format in UTC
+
+Grouped AVP and AVP that is not in dictionary are returned as hex digits.
+*/
+func ValueString(a RawAVP) (string, error) {
+	d, ok := LookupAVP(a.Code, a.VenID)
+	if !ok {
+		return hex.EncodeToString(a.data), nil
+	}
+	return valueString(a, d.Type)
+}
+
+func valueString(a RawAVP, typ string) (string, error) {
+	b := a.data
+	switch typ {
+	case "Integer32", "Enumerated":
+		if len(b) == 4 {
+			return strconv.FormatInt(int64(int32(binary.BigEndian.Uint32(b))), 10), nil
+		}
+	case "Integer64":
+		if len(b) == 8 {
+			return strconv.FormatInt(int64(binary.BigEndian.Uint64(b)), 10), nil
+		}
+	case "Unsigned32":
+		if len(b) == 4 {
+			return strconv.FormatUint(uint64(binary.BigEndian.Uint32(b)), 10), nil
+		}
+	case "Unsigned64":
+		if len(b) == 8 {
+			return strconv.FormatUint(binary.BigEndian.Uint64(b), 10), nil
+		}
+	case "Float32":
+		if len(b) == 4 {
+			return strconv.FormatFloat(
+				float64(math.Float32frombits(binary.BigEndian.Uint32(b))), 'g', -1, 32), nil
+		}
+	case "Float64":
+		if len(b) == 8 {
+			return strconv.FormatFloat(
+				math.Float64frombits(binary.BigEndian.Uint64(b)), 'g', -1, 64), nil
+		}
+	case "UTF8String", "DiameterIdentity", "DiameterURI",
+		"IPFilterRule", "QoSFilterRule":
+		if utf8.Valid(b) {
+			return string(b), nil
+		}
+	case "Address":
+		ad, e := parseAddress(b)
+		if e != nil {
+			break
+		}
+		if ip := ad.IP(); ip != nil {
+			return ip.String(), nil
+		}
+		return fmt.Sprintf("%d:%s", ad.Family, hex.EncodeToString(ad.Value)), nil
+	case "Time":
+		var t time.Time
+		if e := a.Decode(&t); e == nil {
+			return t.UTC().Format(time.RFC3339), nil
+		}
+	default:
+		return hex.EncodeToString(b), nil
+	}
+	return "", fmt.Errorf("invalid %s value: % x", typ, b)
+}
+
+// SetValueString set value of the AVP from text of ValueString.
+// Enumerated value can also be specified by name in dictionary.
+func (a *RawAVP) SetValueString(s string) error {
+	d, ok := LookupAVP(a.Code, a.VenID)
+	if !ok {
+		d = DictAVP{Type: "OctetString"}
+	}
+	return a.setValueString(s, d)
+}
+
+func (a *RawAVP) setValueString(s string, d DictAVP) (e error) {
+	switch d.Type {
+	case "Integer32":
+		var v int64
+		if v, e = strconv.ParseInt(s, 10, 32); e == nil {
+			e = a.Encode(int32(v))
+		}
+	case "Enumerated":
+		for k, n := range d.Enum {
+			if n == s {
+				return a.Encode(Enumerated(k))
+			}
+		}
+		var v int64
+		if v, e = strconv.ParseInt(s, 10, 32); e == nil {
+			e = a.Encode(Enumerated(v))
+		}
+	case "Integer64":
+		var v int64
+		if v, e = strconv.ParseInt(s, 10, 64); e == nil {
+			e = a.Encode(v)
+		}
+	case "Unsigned32":
+		var v uint64
+		if v, e = strconv.ParseUint(s, 10, 32); e == nil {
+			e = a.Encode(uint32(v))
+		}
+	case "Unsigned64":
+		var v uint64
+		if v, e = strconv.ParseUint(s, 10, 64); e == nil {
+			e = a.Encode(v)
+		}
+	case "Float32":
+		var v float64
+		if v, e = strconv.ParseFloat(s, 32); e == nil {
+			e = a.Encode(float32(v))
+		}
+	case "Float64":
+		var v float64
+		if v, e = strconv.ParseFloat(s, 64); e == nil {
+			e = a.Encode(v)
+		}
+	case "UTF8String", "DiameterIdentity", "DiameterURI",
+		"IPFilterRule", "QoSFilterRule":
+		e = a.Encode(s)
+	case "Address":
+		if ip := net.ParseIP(s); ip != nil {
+			return a.Encode(IPAddress(ip))
+		}
+		i := strings.Index(s, ":")
+		if i < 0 {
+			return fmt.Errorf("invalid address %s", s)
+		}
+		var f uint64
+		var v []byte
+		if f, e = strconv.ParseUint(s[:i], 10, 16); e != nil {
+			return fmt.Errorf("invalid address family %s", s[:i])
+		}
+		if v, e = hex.DecodeString(s[i+1:]); e == nil {
+			e = a.Encode(Address{Family: uint16(f), Value: v})
+		}
+	case "Time":
+		var t time.Time
+		if t, e = time.Parse(time.RFC3339, s); e == nil {
+			e = a.Encode(t)
+		}
+	default:
+		var v []byte
+		if v, e = hex.DecodeString(strings.TrimPrefix(s, "0x")); e == nil {
+			e = a.Encode(v)
+		}
+	}
+	return
+}